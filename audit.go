@@ -0,0 +1,69 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// audit writes management action with the service to event log of the service: who has done it, what has changed
+// and whether it has failed, so enterprise environments get audit trail. It returns err.
+func audit(op, name, details string, err error) error {
+	if name == "" {
+		return err
+	}
+
+	l, errLog := OpenEventLog(name)
+	if errLog != nil {
+		return err
+	}
+	defer l.Close()
+
+	l.Report(auditLevel(err), 0, codeAudit, auditMessage(op, name, currentUser(), details, err))
+	return err
+}
+
+// auditLevel returns level of audit event, failed action is a warning.
+func auditLevel(err error) Level {
+	if err != nil {
+		return LevelWarning
+	}
+	return LevelInfo
+}
+
+// auditMessage returns message of audit event, time of action is the time of event.
+func auditMessage(op, name, user, details string, err error) string {
+	msg := fmt.Sprintf("%s of service %s by %s", op, name, user)
+	if details != "" {
+		msg += ": " + details
+	}
+
+	if err != nil {
+		return msg + "; failed: " + err.Error()
+	}
+	return msg
+}
+
+// currentUser returns DOMAIN\user of the process.
+func currentUser() string {
+	u, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "unknown"
+	}
+
+	account, domain, _, err := u.User.Sid.LookupAccount("")
+	if err != nil {
+		return u.User.Sid.String()
+	}
+	return domain + `\` + account
+}
+
+// argsDetails returns details of arguments of start, values are not written, they can be secrets.
+func argsDetails(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d arguments", len(args))
+}
@@ -0,0 +1,25 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAuditMessage(t *testing.T) {
+	tests := []struct {
+		details string
+		err     error
+		exp     string
+	}{
+		{"", nil, `stop of service app by DOMAIN\admin`},
+		{"2 arguments", errors.New("access denied"), `stop of service app by DOMAIN\admin: 2 arguments; failed: access denied`},
+	}
+
+	for _, tt := range tests {
+		if got := auditMessage("stop", "app", `DOMAIN\admin`, tt.details, tt.err); got != tt.exp {
+			t.Errorf("exp: %s, got: %s", tt.exp, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package winsvc
+
+import "time"
+
+// backoff is an exponential delay of restarts with a cap and a limit of attempts.
+type backoff struct {
+	min, max time.Duration
+	attempts int // 0 is unlimited
+	n        int // count of restarts in a row
+}
+
+// next returns delay of the next restart, it returns false if attempts are exhausted.
+func (b *backoff) next() (time.Duration, bool) {
+	if b.attempts > 0 && b.n >= b.attempts {
+		return 0, false
+	}
+
+	d := b.min
+	for i := 0; i < b.n && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		d = b.max
+	}
+	b.n++
+	return d, true
+}
+
+// reset resets count of restarts, it is called when run is stable.
+func (b *backoff) reset() {
+	b.n = 0
+}
+
+// limiter limits restarts which are requested by a watchdog: the next restart is allowed after delay of backoff,
+// restarts which are rarer than the cap of delay are not counted in a row.
+type limiter struct {
+	backoff
+	last, earliest time.Time
+}
+
+// allow reports whether restart is allowed at now, exhausted is true when restarts in a row are exhausted.
+func (l *limiter) allow(now time.Time) (ok, exhausted bool) {
+	if now.Before(l.earliest) {
+		return false, false
+	}
+
+	if !l.last.IsZero() && now.Sub(l.last) >= l.max {
+		l.reset()
+	}
+
+	d, ok := l.next()
+	if !ok {
+		return false, true
+	}
+	l.last, l.earliest = now, now.Add(d)
+	return true, false
+}
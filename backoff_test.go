@@ -0,0 +1,51 @@
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{min: time.Second, max: time.Second * 5, attempts: 5}
+	exp := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}
+	for i, e := range exp {
+		got, ok := b.next()
+		if !ok || got != e {
+			t.Errorf("attempt %d exp: %v, got: %v %t", i, e, got, ok)
+		}
+	}
+
+	if _, ok := b.next(); ok {
+		t.Errorf("exp: attempts are exhausted")
+	}
+
+	b.reset()
+	if got, ok := b.next(); !ok || got != time.Second {
+		t.Errorf("exp: %v, got: %v %t", time.Second, got, ok)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := &limiter{backoff: backoff{min: time.Minute, max: time.Hour, attempts: 2}}
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if ok, _ := l.allow(now); !ok {
+		t.Fatal("exp: the first restart is allowed")
+	}
+	if ok, exhausted := l.allow(now.Add(time.Second * 30)); ok || exhausted {
+		t.Errorf("exp: restart is delayed, got: %t %t", ok, exhausted)
+	}
+	if ok, _ := l.allow(now.Add(time.Minute)); !ok {
+		t.Errorf("exp: restart after delay")
+	}
+	if ok, exhausted := l.allow(now.Add(time.Minute * 3)); ok || !exhausted {
+		t.Errorf("exp: restarts are exhausted, got: %t %t", ok, exhausted)
+	}
+
+	// rare restarts are not in a row
+	l = &limiter{backoff: backoff{min: time.Minute, max: time.Hour, attempts: 1}}
+	l.allow(now)
+	if ok, _ := l.allow(now.Add(time.Hour)); !ok {
+		t.Errorf("exp: restart after the cap of delay")
+	}
+}
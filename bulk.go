@@ -0,0 +1,99 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+)
+
+// Result is a result of bulk operation for one service.
+type Result struct {
+	Name  string
+	State State // state of the service after the operation, it is unknown (0) on error
+	Err   error
+}
+
+// StartAll starts services concurrently by at most parallelism workers (0 is one worker per service)
+// and returns results in order of names.
+func StartAll(names []string, parallelism int) []Result {
+	return forEach(names, parallelism, func(name string) Result {
+		if err := Start(name); err != nil {
+			return Result{Name: name, Err: err}
+		}
+		return Result{Name: name, State: Running}
+	})
+}
+
+// StopAll stops services concurrently by at most parallelism workers (0 is one worker per service)
+// and returns results in order of names.
+func StopAll(names []string, parallelism int) []Result {
+	return forEach(names, parallelism, func(name string) Result {
+		if err := Stop(name); err != nil {
+			return Result{Name: name, Err: err}
+		}
+		return Result{Name: name, State: Stopped}
+	})
+}
+
+// StatusAll queries states of services concurrently by at most parallelism workers (0 is one worker per service)
+// and returns results in order of names.
+func StatusAll(names []string, parallelism int) []Result {
+	return forEach(names, parallelism, func(name string) Result {
+		state, err := Status(name)
+		return Result{Name: name, State: state, Err: err}
+	})
+}
+
+// forEach calls f for every name by pool of workers and returns results in order of names.
+func forEach(names []string, parallelism int, f func(name string) Result) []Result {
+	if parallelism <= 0 || parallelism > len(names) {
+		parallelism = len(names)
+	}
+
+	results := make([]Result, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = f(names[i])
+			}
+		}()
+	}
+
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// printResults prints results in table and returns error if any operation has failed.
+func printResults(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tERROR")
+	failed := 0
+	for _, r := range results {
+		msg := "-"
+		if r.Err != nil {
+			msg = r.Err.Error()
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, r.State, msg)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d services have failed", failed, len(results))
+	}
+	return nil
+}
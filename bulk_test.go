@@ -0,0 +1,52 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	var running, peak int32
+	results := forEach(names, 2, func(name string) Result {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 10)
+		atomic.AddInt32(&running, -1)
+		return Result{Name: name, Err: fmt.Errorf("%s failed", name)}
+	})
+
+	if peak > 2 {
+		t.Errorf("exp: at most %d workers, got: %d", 2, peak)
+	}
+
+	for i, r := range results {
+		if r.Name != names[i] || r.Err == nil {
+			t.Errorf("exp: result of %s, got: %+v", names[i], r)
+		}
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	var b strings.Builder
+	err := printResults(&b, []Result{{Name: "a", State: Running}, {Name: "b", Err: errors.New("access denied")}})
+	if err == nil || err.Error() != "1 of 2 services have failed" {
+		t.Errorf("exp: failed services, got: %v", err)
+	}
+
+	exp := "NAME  STATE    ERROR\na     running  -\nb     unknown  access denied\n"
+	if got := b.String(); got != exp {
+		t.Errorf("exp: %q, got: %q", exp, got)
+	}
+}
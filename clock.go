@@ -0,0 +1,73 @@
+package winsvc
+
+import "time"
+
+// clock is a source of time of the manager, it is replaced in tests to control timeouts.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) timer
+}
+
+// timer is a timer of clock.
+type timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// realClock is the clock of package time.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) timer         { return realTimer{time.NewTimer(d)} }
+
+// realTimer is time.Timer.
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// deadline is a reusable timer of the control loop, its channel is nil when it is not set.
+type deadline struct {
+	t   timer
+	set bool
+}
+
+// reset sets the deadline after d, timer is created once and reused.
+func (dl *deadline) reset(c clock, d time.Duration) {
+	if dl.t == nil {
+		dl.t = c.NewTimer(d)
+	} else {
+		dl.stop()
+		dl.t.Reset(d)
+	}
+	dl.set = true
+}
+
+// stop unsets the deadline.
+func (dl *deadline) stop() {
+	if dl.t != nil && !dl.t.Stop() && dl.set {
+		// timer has fired, but its value is not received
+		select {
+		case <-dl.t.C():
+		default:
+		}
+	}
+	dl.set = false
+}
+
+// fired marks the deadline as unset after its value is received.
+func (dl *deadline) fired() {
+	dl.set = false
+}
+
+// C returns channel of the deadline, it is nil if the deadline is not set.
+func (dl *deadline) C() <-chan time.Time {
+	if !dl.set {
+		return nil
+	}
+	return dl.t.C()
+}
@@ -0,0 +1,149 @@
+package winsvc
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock which time is changed by Advance.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) timer {
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves time forward and fires expired timers.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	active := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			active = append(active, t)
+			continue
+		}
+
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+	}
+	c.timers = active
+}
+
+// WaitTimers waits until n timers are active.
+func (c *fakeClock) WaitTimers(n int) {
+	for {
+		c.mu.Lock()
+		active := len(c.timers)
+		c.mu.Unlock()
+		if active >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// fakeTimer is a timer of fakeClock.
+type fakeTimer struct {
+	c  *fakeClock
+	at time.Time
+	ch chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	for i, a := range t.c.timers {
+		if a == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	active := t.Stop()
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	t.at = t.c.now.Add(d)
+	t.c.timers = append(t.c.timers, t)
+	return active
+}
+
+func TestFakeClock_Advance(t *testing.T) {
+	c := newFakeClock()
+	after := c.After(time.Minute)
+	stopped := c.NewTimer(time.Second)
+	stopped.Stop()
+
+	c.Advance(time.Second * 59)
+	select {
+	case <-after:
+		t.Fatal("exp: timer is not fired")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-after:
+	default:
+		t.Error("exp: timer is fired")
+	}
+
+	select {
+	case <-stopped.C():
+		t.Error("exp: stopped timer is not fired")
+	default:
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	c := newFakeClock()
+	var dl deadline
+	if dl.C() != nil {
+		t.Fatal("exp: nil channel of not set deadline")
+	}
+
+	dl.reset(c, time.Second)
+	c.Advance(time.Second)
+	dl.reset(c, time.Minute) // fired value is dropped
+	select {
+	case <-dl.C():
+		t.Fatal("exp: deadline is not fired")
+	default:
+	}
+
+	c.Advance(time.Minute)
+	<-dl.C()
+	dl.fired()
+	if dl.C() != nil {
+		t.Error("exp: nil channel of fired deadline")
+	}
+}
@@ -0,0 +1,302 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Command is an action of the command line of service program.
+//
+//	program.exe install
+//	program.exe config -json
+type Command string
+
+// Actions of the command line, program runs the service if action is not set.
+const (
+	CmdRun       Command = "run"
+	CmdInstall   Command = "install"
+	CmdUninstall Command = "uninstall"
+	CmdStart     Command = "start"
+	CmdStop      Command = "stop"
+	CmdRestart   Command = "restart"
+	CmdStatus    Command = "status"
+	CmdConfig    Command = "config"
+	CmdList      Command = "list"
+	CmdVersion   Command = "version"
+	CmdLogs      Command = "logs"
+	CmdDoctor    Command = "doctor"
+
+	CmdRotatePassword Command = "rotate-password"
+	CmdCompletion     Command = "completion" // prints script of completion of shell: powershell or bash
+	CmdStartAll       Command = "start-all"  // starts all instances of the executable
+	CmdStopAll        Command = "stop-all"   // stops all instances of the executable
+
+	cmdComplete Command = "__complete" // prints candidates of the next word, it is called by scripts of completion
+)
+
+// parseCommand returns action of the command line and its arguments.
+// It returns false if the first argument is not action.
+func parseCommand(args []string) (Command, []string, bool) {
+	if len(args) == 0 {
+		return CmdRun, nil, false
+	}
+
+	switch cmd := Command(args[0]); cmd {
+	case CmdRun, CmdInstall, CmdUninstall, CmdStart, CmdStop, CmdRestart, CmdStatus, CmdConfig, CmdList, CmdVersion, CmdLogs, CmdDoctor,
+		CmdRotatePassword, CmdCompletion, CmdStartAll, CmdStopAll, cmdComplete:
+		return cmd, args[1:], true
+	}
+	return CmdRun, nil, false
+}
+
+// allowCommand reports whether action of the command line is handled (see WithCommands).
+func (m *manager) allowCommand(cmd Command) bool {
+	if m.commands == nil {
+		return true
+	}
+	if cmd == cmdComplete {
+		cmd = CmdCompletion
+	}
+	return m.commands[cmd]
+}
+
+// runFlags are flags of action run.
+type runFlags struct {
+	console bool   // run in console mode
+	name    string // name of the service instance
+}
+
+// parseRunFlags returns flags of action run, it returns false if action is not run or flags are invalid.
+func parseRunFlags(args []string) (runFlags, bool) {
+	cmd, rest, ok := parseCommand(args)
+	if !ok || cmd != CmdRun {
+		return runFlags{}, false
+	}
+
+	var f runFlags
+	fs := flag.NewFlagSet(string(cmd), flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.BoolVar(&f.console, "console", false, "run in console mode")
+	fs.StringVar(&f.name, "name", "", "name of the service instance")
+	if err := fs.Parse(rest); err != nil {
+		return runFlags{}, false
+	}
+	return f, true
+}
+
+// commandFlags are flags of actions.
+type commandFlags struct {
+	name     string
+	asJSON   bool
+	restart  bool
+	keepData bool
+	wait     time.Duration
+	since    time.Duration
+	level    string
+	follow   bool
+	parallel int
+}
+
+// newCommandFlags returns set of flags of the action, flags of output are set to o.
+func newCommandFlags(cmd Command, o *output) (*flag.FlagSet, *commandFlags) {
+	var f commandFlags
+	fs := flag.NewFlagSet(string(cmd), flag.ContinueOnError)
+	fs.SetOutput(o.err)
+	fs.StringVar(&f.name, "name", "", "name of the service, default is name of the configuration")
+	fs.BoolVar(&o.verbose, "verbose", false, "print debug messages")
+	fs.BoolVar(&o.quiet, "quiet", false, "print only results and errors")
+	fs.BoolVar(&f.asJSON, "json", false, "print in json form (config)")
+	fs.BoolVar(&f.restart, "restart", false, "restart running service (rotate-password)")
+	fs.BoolVar(&f.keepData, "keep-data", false, "keep directories of data (uninstall)")
+	fs.DurationVar(&f.wait, "wait", timeoutWait, "time of waiting running or stopped state (start, stop)")
+	fs.DurationVar(&f.since, "since", 0, "print entries of event log which are written in the duration, default is all (logs)")
+	fs.StringVar(&f.level, "level", "info", "minimum level of entries of event log: info, warning or error (logs)")
+	fs.BoolVar(&f.follow, "follow", false, "print new entries of event log until interrupt (logs)")
+	fs.IntVar(&f.parallel, "parallel", 4, "count of services which are started or stopped at once, 0 is all (start-all, stop-all)")
+	return fs, &f
+}
+
+// command executes action of the command line, results are written to stdout and messages to stderr.
+func (m *manager) command(o *output, cmd Command, args []string) error {
+	if cmd == cmdComplete {
+		return m.complete(o, args)
+	}
+
+	fs, f := newCommandFlags(cmd, o)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	if o.verbose && o.quiet {
+		return fmt.Errorf("%w: flags -verbose and -quiet are exclusive", errUsage)
+	}
+
+	// sibling service is managed by the same binary, for example old instance is stopped before install of new one
+	if f.name != "" {
+		m.instance = f.name
+	}
+
+	c, err := m.effectiveConfig()
+	if err != nil {
+		return err
+	}
+	m.applyConfig(c)
+	o.debugf("service %s, executable %s", c.Name, c.Executable)
+
+	switch cmd {
+	case CmdInstall:
+		if err := m.installWithHooks(c); err != nil {
+			return err
+		}
+		o.infof("service %s is installed", c.Name)
+
+		// other services are not changed, but the operator is warned
+		unquoted, _ := UnquotedServicePaths()
+		for _, p := range unquoted {
+			o.warnf("service %s has unquoted path with spaces: %s", p.Name, p.ImagePath)
+		}
+		return nil
+	case CmdUninstall:
+		if err := m.uninstallWithHooks(c, f.keepData); err != nil {
+			return err
+		}
+		o.infof("service %s is uninstalled", c.Name)
+		return nil
+	case CmdStart:
+		update, stop := o.progress(realClock{}, f.wait, progressInterval)
+		err := StartWait(c.Name, f.wait, update, fs.Args()...)
+		stop()
+		if err != nil {
+			return err
+		}
+		o.infof("service %s is running", c.Name)
+		return nil
+	case CmdStop:
+		update, stop := o.progress(realClock{}, f.wait, progressInterval)
+		err := StopWait(c.Name, f.wait, update)
+		stop()
+		if err != nil {
+			return err
+		}
+		o.infof("service %s is stopped", c.Name)
+		return nil
+	case CmdRestart:
+		if err := Restart(c.Name, fs.Args()...); err != nil {
+			return err
+		}
+		o.infof("service %s is restarted", c.Name)
+		return nil
+	case CmdStatus:
+		state, err := Status(c.Name)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(o, state)
+		return err
+	case CmdConfig:
+		return m.printConfig(o, c, f.asJSON)
+	case CmdLogs:
+		min, err := parseLevel(f.level)
+		if err != nil {
+			return err
+		}
+
+		var from time.Time
+		if f.since > 0 {
+			from = time.Now().Add(-f.since)
+		}
+		return printLogs(o, c.Name, from, min, f.follow)
+	case CmdDoctor:
+		failed, err := printChecks(o, doctor(c))
+		if err == nil && failed > 0 {
+			err = fmt.Errorf("%d problems are found", failed)
+		}
+		return err
+	case CmdVersion:
+		return m.printVersion(o)
+	case CmdList:
+		list, err := Instances(c.Executable)
+		if err != nil {
+			return err
+		}
+		return printInstances(o, list)
+	case CmdStartAll, CmdStopAll:
+		list, err := Instances(c.Executable)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(list))
+		for i, s := range list {
+			names[i] = s.Name
+		}
+		o.debugf("instances: %s", strings.Join(names, ", "))
+
+		all := StartAll
+		if cmd == CmdStopAll {
+			all = StopAll
+		}
+		return printResults(o, all(names, f.parallel))
+	case CmdCompletion:
+		return printCompletion(o, fs.Arg(0), c.Executable)
+	case CmdRotatePassword:
+		pw, err := readPassword(c)
+		if err != nil {
+			return err
+		}
+		defer pw.wipe()
+		if err := setPassword(c.Name, pw, f.restart); err != nil {
+			return err
+		}
+		o.infof("password of service %s is changed", c.Name)
+		return nil
+	}
+	return fmt.Errorf("%w: unknown command %s", errUsage, cmd)
+}
+
+// runCommand executes action of the command line of process.
+// It returns false if the service must be run.
+func (m *manager) runCommand() (bool, error) {
+	cmd, args, ok := parseCommand(os.Args[1:])
+	if !ok || cmd == CmdRun || !m.allowCommand(cmd) {
+		return false, nil
+	}
+
+	o := &output{out: os.Stdout, err: os.Stderr}
+	if err := m.command(o, cmd, args); err != nil {
+		if m.returnErr {
+			return true, fmt.Errorf("%s: %w", cmd, err)
+		}
+		o.errorf("%s: %v", cmd, err)
+		os.Exit(ExitCode(err))
+	}
+	return true, nil
+}
+
+// readPassword returns new password from Credential Manager if Config.PasswordCredential is set
+// or reads it from the first line of stdin, password is not accepted as argument to not show it in list of processes.
+// Password must be wiped.
+func readPassword(c Config) (*password, error) {
+	if c.PasswordCredential != "" {
+		_, pw, err := accountPassword(c)
+		return pw, err
+	}
+
+	pw, err := readPasswordLine(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	if pw.empty() {
+		return nil, errors.New("password is empty")
+	}
+	return pw, nil
+}
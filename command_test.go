@@ -0,0 +1,143 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		cmd  Command
+		rest []string
+		ok   bool
+	}{
+		{nil, CmdRun, nil, false},
+		{[]string{"-test.v"}, CmdRun, nil, false},
+		{[]string{"run"}, CmdRun, []string{}, true},
+		{[]string{"config", "-json"}, CmdConfig, []string{"-json"}, true},
+		{[]string{"list"}, CmdList, []string{}, true},
+		{[]string{"logs", "-since", "1h", "-follow"}, CmdLogs, []string{"-since", "1h", "-follow"}, true},
+		{[]string{"rotate-password", "-restart"}, CmdRotatePassword, []string{"-restart"}, true},
+	}
+
+	for _, tt := range tests {
+		cmd, rest, ok := parseCommand(tt.args)
+		if cmd != tt.cmd || ok != tt.ok || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("%v: exp: %s %v %t, got: %s %v %t", tt.args, tt.cmd, tt.rest, tt.ok, cmd, rest, ok)
+		}
+	}
+}
+
+func TestParseRunFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		exp  runFlags
+		ok   bool
+	}{
+		{nil, runFlags{}, false},
+		{[]string{"run"}, runFlags{}, true},
+		{[]string{"run", "-console"}, runFlags{console: true}, true},
+		{[]string{"run", "--console"}, runFlags{console: true}, true},
+		{[]string{"run", "--name", "svc-2"}, runFlags{name: "svc-2"}, true},
+		{[]string{"run", "-console", "-name=svc-2"}, runFlags{console: true, name: "svc-2"}, true},
+		{[]string{"start", "-console"}, runFlags{}, false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := parseRunFlags(tt.args); got != tt.exp || ok != tt.ok {
+			t.Errorf("%v: exp: %+v %t, got: %+v %t", tt.args, tt.exp, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestManager_RunCommandProgramArgs(t *testing.T) {
+	old := os.Args
+	defer func() { os.Args = old }()
+
+	tests := []struct {
+		args []string
+		opts []option
+	}{
+		{[]string{"app", "serve", "-port", "80"}, nil},
+		{[]string{"app", "run", "-console"}, nil},
+		{[]string{"app", "status"}, []option{WithCommands(CmdInstall, CmdUninstall)}},
+		{[]string{"app", "config", "-json"}, []option{WithCommands()}},
+	}
+
+	for _, tt := range tests {
+		os.Args = tt.args
+		m := newManager(nil, tt.opts...)
+		if ok, err := m.runCommand(); ok || err != nil {
+			t.Errorf("%v: exp: arguments of the program, got: %t %v", tt.args, ok, err)
+		}
+	}
+}
+
+func TestManager_CompleteCommands(t *testing.T) {
+	m := newManager(nil, WithCommands(CmdInstall, CmdUninstall))
+	var b bytes.Buffer
+	if err := m.complete(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := b.String(), "install\nuninstall\n"; got != exp {
+		t.Errorf("exp: %q, got: %q", exp, got)
+	}
+}
+
+func TestManager_Complete(t *testing.T) {
+	m := newManager(nil)
+	tests := []struct {
+		args []string
+		exp  string
+	}{
+		{nil, "run\ninstall\nuninstall\nstart\nstop\nrestart\nstatus\nconfig\nlist\nversion\nlogs\ndoctor\nrotate-password\ncompletion\nstart-all\nstop-all\n"},
+		{[]string{"completion"}, "powershell\nbash\n"},
+		{[]string{"run", "-console"}, "-console\n-name\n"},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := m.complete(&b, tt.args); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := b.String(); got != tt.exp {
+			t.Errorf("%v exp: %q, got: %q", tt.args, tt.exp, got)
+		}
+	}
+}
+
+func TestPrintCompletion(t *testing.T) {
+	var b bytes.Buffer
+	if err := printCompletion(&b, "bash", `C:\Program Files\my-app.exe`); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.String(); !strings.Contains(got, "complete -F _my_app_complete my-app my-app.exe") ||
+		!strings.Contains(got, "'C:/Program Files/my-app.exe' __complete") {
+		t.Errorf("exp: bash script, got: %s", got)
+	}
+
+	if err := printCompletion(&b, "fish", "app.exe"); err == nil {
+		t.Error("exp: error of unknown shell")
+	}
+}
+
+func TestNewCommandFlags_Name(t *testing.T) {
+	var o output
+	fs, f := newCommandFlags(CmdStop, &o)
+	if err := fs.Parse([]string{"--name", "svc-old", "-quiet"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.name != "svc-old" || !o.quiet {
+		t.Errorf("exp: svc-old quiet, got: %s %v", f.name, o.quiet)
+	}
+}
@@ -0,0 +1,113 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// actions are actions of the command line which are completed.
+var actions = []Command{CmdRun, CmdInstall, CmdUninstall, CmdStart, CmdStop, CmdRestart, CmdStatus, CmdConfig,
+	CmdList, CmdVersion, CmdLogs, CmdDoctor, CmdRotatePassword, CmdCompletion, CmdStartAll, CmdStopAll}
+
+// completionPowerShell calls the program with previous words and filters candidates by the current word.
+const completionPowerShell = `Register-ArgumentCompleter -Native -CommandName '%[1]s', '%[1]s.exe' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '' -and $words.Count -gt 0) { $words = @($words | Select-Object -SkipLast 1) }
+    & '%[2]s' __complete @words | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+// completionBash calls the program with previous words, it is used by Git Bash, MSYS2 and WSL.
+const completionBash = `_%[3]s_complete() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    COMPREPLY=($(compgen -W "$('%[2]s' __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" | tr -d '\r')" -- "$cur"))
+}
+complete -F _%[3]s_complete %[1]s %[1]s.exe
+`
+
+// printCompletion prints script of completion of the shell for the executable.
+//
+//	program.exe completion powershell | Out-String | Invoke-Expression
+func printCompletion(w io.Writer, shell, exe string) error {
+	name := strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	switch strings.ToLower(shell) {
+	case "powershell", "pwsh":
+		_, err := fmt.Fprintf(w, completionPowerShell, name, strings.Replace(exe, "'", "''", -1))
+		return err
+	case "bash":
+		fn := strings.Map(func(r rune) rune {
+			if r == '-' || r == '.' || r == ' ' {
+				return '_'
+			}
+			return r
+		}, name)
+		_, err := fmt.Fprintf(w, completionBash, name, filepath.ToSlash(exe), fn)
+		return err
+	case "cmd":
+		return errors.New("cmd has no programmable completion, use powershell")
+	}
+	return fmt.Errorf("%w: unknown shell %q, expected powershell or bash", errUsage, shell)
+}
+
+// complete prints candidates of the word after args: actions, flags of the action and names of instances
+// of the executable after flag -name, names are found by the service manager.
+func (m *manager) complete(w io.Writer, args []string) error {
+	if len(args) == 0 {
+		for _, a := range actions {
+			if m.allowCommand(a) {
+				fmt.Fprintln(w, a)
+			}
+		}
+		return nil
+	}
+
+	prev := strings.TrimLeft(args[len(args)-1], "-")
+	switch {
+	case prev == "name":
+		c, err := m.effectiveConfig()
+		if err != nil {
+			return err
+		}
+
+		list, err := Instances(c.Executable)
+		if err != nil {
+			return err
+		}
+		for _, s := range list {
+			fmt.Fprintln(w, s.Name)
+		}
+		return nil
+	case len(args) == 1 && Command(args[0]) == CmdCompletion:
+		fmt.Fprintln(w, "powershell")
+		fmt.Fprintln(w, "bash")
+		return nil
+	}
+
+	for _, f := range actionFlags(Command(args[0])) {
+		fmt.Fprintln(w, "-"+f)
+	}
+	return nil
+}
+
+// actionFlags returns names of flags of the action.
+func actionFlags(cmd Command) []string {
+	if cmd == CmdRun {
+		return []string{"console", "name"}
+	}
+
+	var names []string
+	fs, _ := newCommandFlags(cmd, &output{})
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return names
+}
@@ -0,0 +1,108 @@
+package winsvc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Components are parts of the service which are started in order of adding and stopped in reverse order.
+// Run has signature of run function of the service.
+//
+//	var c winsvc.Components
+//	c.AddComponent("db", db.Open, db.Close, time.Second*10)
+//	c.AddComponent("http", srv.Start, srv.Shutdown, time.Second*5)
+//	winsvc.Run(c.Run)
+type Components struct {
+	OnError func(err error) // called with errors of Run, default writes them by log
+
+	list    []component
+	started int // count of started components
+}
+
+// component is a part of the service.
+type component struct {
+	name        string
+	start, stop func(ctx context.Context) error
+	timeout     time.Duration
+}
+
+// AddComponent adds component with functions of start and stop, any of them can be nil.
+// Context of every function is done after timeout, 0 is without timeout.
+func (c *Components) AddComponent(name string, start, stop func(ctx context.Context) error, timeout time.Duration) {
+	c.list = append(c.list, component{name: name, start: start, stop: stop, timeout: timeout})
+}
+
+// Start starts components in order. If one of them fails, started components are stopped
+// and error with name of the failed component is returned.
+func (c *Components) Start(ctx context.Context) error {
+	for _, cp := range c.list[c.started:] {
+		if err := cp.call(ctx, cp.start); err != nil {
+			c.Stop(context.Background())
+			return fmt.Errorf("start component %s: %w", cp.name, err)
+		}
+		c.started++
+	}
+	return nil
+}
+
+// Stop stops started components in reverse order, every component is stopped even if previous one fails.
+// It returns the first error.
+func (c *Components) Stop(ctx context.Context) error {
+	var first error
+	for ; c.started > 0; c.started-- {
+		cp := c.list[c.started-1]
+		if err := cp.call(ctx, cp.stop); err != nil && first == nil {
+			first = fmt.Errorf("stop component %s: %w", cp.name, err)
+		}
+	}
+	return first
+}
+
+// Run starts components, waits until context is canceled and stops them.
+// It returns at once if start fails, so the service is failed.
+func (c *Components) Run(ctx context.Context) {
+	if err := c.Start(ctx); err != nil {
+		c.error(err)
+		return
+	}
+
+	<-ctx.Done()
+	if err := c.Stop(context.Background()); err != nil {
+		c.error(err)
+	}
+}
+
+// error reports error of Run.
+func (c *Components) error(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+		return
+	}
+	log.Printf("[ERROR] %v", err)
+}
+
+// call calls f with context which is done after timeout of the component, f returns error of timeout if it does not return in time.
+func (cp component) call(ctx context.Context, f func(ctx context.Context) error) error {
+	if f == nil {
+		return nil
+	}
+
+	if cp.timeout <= 0 {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cp.timeout)
+	defer cancel()
+
+	res := make(chan error, 1)
+	go func() { res <- f(ctx) }()
+
+	select {
+	case err := <-res:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timeout %s: %w", cp.timeout, ctx.Err())
+	}
+}
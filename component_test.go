@@ -0,0 +1,60 @@
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComponents_Order(t *testing.T) {
+	var (
+		c     Components
+		calls []string
+	)
+	for _, name := range []string{"db", "cache", "http"} {
+		name := name
+		c.AddComponent(name,
+			func(ctx context.Context) error { calls = append(calls, "start "+name); return nil },
+			func(ctx context.Context) error { calls = append(calls, "stop "+name); return nil }, 0)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Run(ctx)
+
+	exp := []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"}
+	if !reflect.DeepEqual(calls, exp) {
+		t.Errorf("exp: %v, got: %v", exp, calls)
+	}
+}
+
+func TestComponents_StartFailed(t *testing.T) {
+	var (
+		c     Components
+		calls []string
+	)
+	c.AddComponent("db", nil, func(ctx context.Context) error { calls = append(calls, "stop db"); return nil }, 0)
+	c.AddComponent("http", func(ctx context.Context) error { return errors.New("port is busy") },
+		func(ctx context.Context) error { calls = append(calls, "stop http"); return nil }, 0)
+
+	err := c.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "http") {
+		t.Errorf("exp: error of http, got: %v", err)
+	}
+
+	if exp := []string{"stop db"}; !reflect.DeepEqual(calls, exp) {
+		t.Errorf("exp: %v, got: %v", exp, calls)
+	}
+}
+
+func TestComponents_Timeout(t *testing.T) {
+	var c Components
+	c.AddComponent("slow", func(ctx context.Context) error { time.Sleep(time.Second); return nil }, nil, time.Millisecond*10)
+
+	if err := c.Start(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("exp: %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,366 @@
+package winsvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Start types of the service, values are equal to mgr.StartAutomatic and etc.
+const (
+	StartAutomatic = 2 // the service will start by itself whenever the computer reboots
+	StartManual    = 3 // the service must be started manually
+	StartDisabled  = 4 // the service cannot be started
+)
+
+// Config is a configuration of the installed service.
+type Config struct {
+	Name               string   `json:"name,omitempty"`                // name of the service, default is name of the executable
+	DisplayName        string   `json:"display_name,omitempty"`        // name is shown in services.msc, it can be indirect string "@path,-id" of resource
+	Description        string   `json:"description,omitempty"`         // description is shown in services.msc, it can be indirect string "@path,-id" of resource
+	StartType          uint32   `json:"start_type,omitempty"`          // StartManual, StartAutomatic or StartDisabled, default is StartAutomatic
+	DelayedAutoStart   bool     `json:"delayed_auto_start,omitempty"`  // the service is started after other auto-start services
+	Dependencies       []string `json:"dependencies,omitempty"`        // names of services which must be started before
+	Account            string   `json:"account,omitempty"`             // account under which the service runs, default is LocalSystem
+	Password           string   `json:"-"`                             // password of the account, copies of string can not be wiped, PasswordCredential or PasswordStdin keep it only in locked memory
+	PasswordCredential string   `json:"password_credential,omitempty"` // target of generic credential of Credential Manager, it is used if Password is empty
+	PasswordStdin      bool     `json:"password_stdin,omitempty"`      // password is read from the first line of stdin, it is used if Password and PasswordCredential are empty
+	Executable         string   `json:"executable,omitempty"`          // path to the binary, default is the current executable
+	Interactive        bool     `json:"interactive,omitempty"`         // service can interact with desktop, it is deprecated by Windows (see StartInSession)
+	Args               []string `json:"args,omitempty"`                // arguments are passed to the binary
+
+	// Localized display names and descriptions by locale ("de-DE" or "de"), they are resolved at install
+	// by UI language of the system, DisplayName and Description are used if no locale matches.
+	DisplayNames map[string]string `json:"display_names,omitempty"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+
+	// Tags are written to registry key Tags of the service at install, they are used for discovery (see FindByTag).
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Parameters are written to registry key Parameters of the service at install (see OpenParameters).
+	// Supported values: string, Secret, int, int64, uint32, bool, []string.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	FirewallRules   []FirewallRule `json:"firewall_rules,omitempty"`   // inbound rules of Windows Firewall for the service
+	URLReservations []string       `json:"url_reservations,omitempty"` // URLs of HTTP.sys which are reserved for the account of service
+	Counters        []Counter      `json:"counters,omitempty"`         // performance counters of the service
+	DataDirs        []string       `json:"data_dirs,omitempty"`        // directories of data which are created at install and removed on uninstall
+	CrashDumpDir    string         `json:"crash_dump_dir,omitempty"`   // full dumps of native crashes are written by Windows Error Reporting to the directory
+	CrashDumpCount  int            `json:"crash_dump_count,omitempty"` // count of kept dumps, default is 10
+	ProgramData     bool           `json:"program_data,omitempty"`     // directory %ProgramData%\<name> with logs, data and config is created at install (see ProgramDataDir)
+
+	Hardening *HardeningProfile `json:"hardening,omitempty"` // security restrictions of the service, for example &HardeningStrict
+
+	Recovery        []RecoveryAction `json:"recovery,omitempty"`         // actions of the service manager on failures, the last action is repeated
+	RecoveryReset   int              `json:"recovery_reset,omitempty"`   // period without failures in seconds after which count of failures is reset, default is one day
+	RecoveryCommand string           `json:"recovery_command,omitempty"` // command line of RecoveryRunCommand action
+
+	EventMessageFile   string `json:"event_message_file,omitempty"`   // message file of event log source, default is DefaultEventMessageFile
+	EventCategoryCount uint32 `json:"event_category_count,omitempty"` // count of categories in message file
+
+	// Timeouts of stop in seconds, they override options TimeoutStop, TimeoutShutdown and TimeoutPreShutdown
+	// and they are overridden by registry key Parameters and environment variables (see EnvTimeoutStop).
+	TimeoutStop        int `json:"timeout_stop,omitempty"`
+	TimeoutShutdown    int `json:"timeout_shutdown,omitempty"`
+	TimeoutPreShutdown int `json:"timeout_pre_shutdown,omitempty"`
+
+	LogLevel string `json:"log_level,omitempty"` // minimal level of entries of event log: info, warning or error, default is info
+
+	PostStart   []string `json:"post_start,omitempty"`   // command which is run after start of the service, for example registration in service discovery
+	PreStop     []string `json:"pre_stop,omitempty"`     // command which is run before stop of the service, for example drain script
+	HookTimeout int      `json:"hook_timeout,omitempty"` // timeout of hook commands in seconds, default is 30
+
+	// Commands which are run around install and uninstall actions, name of the service is passed by WINSVC_NAME.
+	PreInstall    []string `json:"pre_install,omitempty"`
+	PostInstall   []string `json:"post_install,omitempty"`
+	PreUninstall  []string `json:"pre_uninstall,omitempty"`
+	PostUninstall []string `json:"post_uninstall,omitempty"`
+}
+
+// FirewallRule is an inbound rule of Windows Firewall which allows connections to the port of the service.
+type FirewallRule struct {
+	Name     string `json:"name"`
+	Port     uint16 `json:"port"`
+	Protocol string `json:"protocol,omitempty"` // tcp or udp, default is tcp
+}
+
+// CounterType is a type of performance counter.
+type CounterType int
+
+// Types of performance counters.
+const (
+	CounterValue CounterType = iota // the last value, for example queue depth or uptime
+	CounterRate                     // the value per second, for example count of requests
+)
+
+// Counter is a performance counter of the service which is shown in PerfMon.
+type Counter struct {
+	ID          uint32      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Type        CounterType `json:"type,omitempty"`
+}
+
+// RecoveryType is a type of recovery action, values are equal to mgr.ServiceRestart and etc.
+type RecoveryType int
+
+// Types of recovery actions.
+const (
+	RecoveryNone       RecoveryType = 0 // no action
+	RecoveryRestart    RecoveryType = 1 // restart the service
+	RecoveryReboot     RecoveryType = 2 // reboot the computer
+	RecoveryRunCommand RecoveryType = 3 // run Config.RecoveryCommand
+)
+
+// defaultRecoveryReset is a period without failures after which count of failures is reset.
+const defaultRecoveryReset = 24 * 60 * 60
+
+// RecoveryAction is an action of the service manager on failure of the service.
+type RecoveryAction struct {
+	Type  RecoveryType `json:"type"`
+	Delay int          `json:"delay,omitempty"` // delay before the action in seconds
+}
+
+// Secret is a value which is not printed and it is encrypted in registry.
+type Secret string
+
+// String hides the value.
+func (s Secret) String() string {
+	return "***"
+}
+
+// MarshalJSON hides the value.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"***"`), nil
+}
+
+// Environment variables override the configuration of the service.
+const (
+	EnvName         = "WINSVC_NAME"
+	EnvDisplayName  = "WINSVC_DISPLAY_NAME"
+	EnvDescription  = "WINSVC_DESCRIPTION"
+	EnvAccount      = "WINSVC_ACCOUNT"
+	EnvDependencies = "WINSVC_DEPENDENCIES" // comma separated names
+	EnvConfigFile   = "WINSVC_CONFIG"       // path to json file of config
+)
+
+// Environment variables and values of registry key Parameters of the service override timeouts of options,
+// value is duration ("30s") or count of seconds. Environment variables have priority.
+const (
+	EnvTimeoutStop        = "WINSVC_TIMEOUT_STOP"
+	EnvTimeoutShutdown    = "WINSVC_TIMEOUT_SHUTDOWN"
+	EnvTimeoutPreShutdown = "WINSVC_TIMEOUT_PRESHUTDOWN"
+
+	ParamTimeoutStop        = "TimeoutStop"
+	ParamTimeoutShutdown    = "TimeoutShutdown"
+	ParamTimeoutPreShutdown = "TimeoutPreShutdown"
+)
+
+// parseTimeout parses duration or count of seconds.
+func parseTimeout(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if sec, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return time.Duration(sec) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	return d, nil
+}
+
+// merge overrides fields of c by not empty fields of o.
+func (c Config) merge(o Config) Config {
+	if o.Name != "" {
+		c.Name = o.Name
+	}
+	if o.DisplayName != "" {
+		c.DisplayName = o.DisplayName
+	}
+	if o.Description != "" {
+		c.Description = o.Description
+	}
+	if len(o.DisplayNames) != 0 {
+		c.DisplayNames = o.DisplayNames
+	}
+	if len(o.Descriptions) != 0 {
+		c.Descriptions = o.Descriptions
+	}
+	if o.StartType != 0 {
+		c.StartType = o.StartType
+	}
+	if o.DelayedAutoStart {
+		c.DelayedAutoStart = true
+	}
+	if len(o.Dependencies) != 0 {
+		c.Dependencies = o.Dependencies
+	}
+	if o.Account != "" {
+		c.Account = o.Account
+	}
+	if o.Password != "" {
+		c.Password = o.Password
+	}
+	if o.PasswordCredential != "" {
+		c.PasswordCredential = o.PasswordCredential
+	}
+	if o.PasswordStdin {
+		c.PasswordStdin = true
+	}
+	if o.Executable != "" {
+		c.Executable = o.Executable
+	}
+	if len(o.Args) != 0 {
+		c.Args = o.Args
+	}
+	if o.Interactive {
+		c.Interactive = true
+	}
+	if len(o.Parameters) != 0 {
+		params := make(map[string]interface{}, len(c.Parameters)+len(o.Parameters))
+		for k, v := range c.Parameters {
+			params[k] = v
+		}
+		for k, v := range o.Parameters {
+			params[k] = v
+		}
+		c.Parameters = params
+	}
+	if len(o.Tags) != 0 {
+		tags := make(map[string]string, len(c.Tags)+len(o.Tags))
+		for k, v := range c.Tags {
+			tags[k] = v
+		}
+		for k, v := range o.Tags {
+			tags[k] = v
+		}
+		c.Tags = tags
+	}
+	if len(o.FirewallRules) != 0 {
+		c.FirewallRules = o.FirewallRules
+	}
+	if len(o.URLReservations) != 0 {
+		c.URLReservations = o.URLReservations
+	}
+	if len(o.Counters) != 0 {
+		c.Counters = o.Counters
+	}
+	if len(o.DataDirs) != 0 {
+		c.DataDirs = o.DataDirs
+	}
+	if o.ProgramData {
+		c.ProgramData = true
+	}
+	if o.Hardening != nil {
+		c.Hardening = o.Hardening
+	}
+	if o.CrashDumpDir != "" {
+		c.CrashDumpDir = o.CrashDumpDir
+	}
+	if o.CrashDumpCount != 0 {
+		c.CrashDumpCount = o.CrashDumpCount
+	}
+	if len(o.Recovery) != 0 {
+		c.Recovery = o.Recovery
+	}
+	if o.RecoveryReset != 0 {
+		c.RecoveryReset = o.RecoveryReset
+	}
+	if o.RecoveryCommand != "" {
+		c.RecoveryCommand = o.RecoveryCommand
+	}
+	if o.EventMessageFile != "" {
+		c.EventMessageFile = o.EventMessageFile
+	}
+	if o.EventCategoryCount != 0 {
+		c.EventCategoryCount = o.EventCategoryCount
+	}
+	if o.TimeoutStop != 0 {
+		c.TimeoutStop = o.TimeoutStop
+	}
+	if o.TimeoutShutdown != 0 {
+		c.TimeoutShutdown = o.TimeoutShutdown
+	}
+	if o.TimeoutPreShutdown != 0 {
+		c.TimeoutPreShutdown = o.TimeoutPreShutdown
+	}
+	if o.LogLevel != "" {
+		c.LogLevel = o.LogLevel
+	}
+	if len(o.PostStart) != 0 {
+		c.PostStart = o.PostStart
+	}
+	if len(o.PreStop) != 0 {
+		c.PreStop = o.PreStop
+	}
+	if o.HookTimeout != 0 {
+		c.HookTimeout = o.HookTimeout
+	}
+	if len(o.PreInstall) != 0 {
+		c.PreInstall = o.PreInstall
+	}
+	if len(o.PostInstall) != 0 {
+		c.PostInstall = o.PostInstall
+	}
+	if len(o.PreUninstall) != 0 {
+		c.PreUninstall = o.PreUninstall
+	}
+	if len(o.PostUninstall) != 0 {
+		c.PostUninstall = o.PostUninstall
+	}
+	return c
+}
+
+// configEnv returns configuration from environment variables.
+func configEnv() Config {
+	c := Config{
+		Name:        os.Getenv(EnvName),
+		DisplayName: os.Getenv(EnvDisplayName),
+		Description: os.Getenv(EnvDescription),
+		Account:     os.Getenv(EnvAccount),
+	}
+
+	if deps := os.Getenv(EnvDependencies); deps != "" {
+		c.Dependencies = strings.Split(deps, ",")
+	}
+	return c
+}
+
+// configFile reads configuration from json file. Not existed file is skipped.
+func configFile(path string) (Config, error) {
+	var c Config
+	if path == "" {
+		return c, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// startTypeString returns human readable start type.
+func startTypeString(t uint32) string {
+	switch t {
+	case StartAutomatic:
+		return "automatic"
+	case StartManual:
+		return "manual"
+	case StartDisabled:
+		return "disabled"
+	}
+	return "unknown"
+}
@@ -0,0 +1,154 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfig_Merge(t *testing.T) {
+	c := Config{Name: "a", Description: "desc", Args: []string{"-v"}}
+	got := c.merge(Config{Name: "b", Account: `.\user`})
+
+	exp := Config{Name: "b", Description: "desc", Account: `.\user`, Args: []string{"-v"}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %+v, got: %+v", exp, got)
+	}
+}
+
+func TestConfig_MergeParameters(t *testing.T) {
+	c := Config{Parameters: map[string]interface{}{"a": "1", "b": 2}}
+	got := c.merge(Config{Parameters: map[string]interface{}{"b": 3, "c": Secret("s")}})
+
+	exp := map[string]interface{}{"a": "1", "b": 3, "c": Secret("s")}
+	if !reflect.DeepEqual(got.Parameters, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got.Parameters)
+	}
+
+	if c.Parameters["b"] != 2 {
+		t.Errorf("exp: original parameters are not changed")
+	}
+}
+
+func TestManager_EffectiveConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "winsvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"name": "file", "description": "from file"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(EnvDescription, "from env")
+	defer os.Unsetenv(EnvDescription)
+
+	m := &manager{config: Config{Name: "option", DisplayName: "Option"}, configFile: path}
+	c, err := m.effectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "file" || c.DisplayName != "Option" || c.Description != "from env" {
+		t.Errorf("exp: merged config, got: %+v", c)
+	}
+
+	if c.Executable == "" || c.StartType == 0 {
+		t.Errorf("exp: defaults, got: %+v", c)
+	}
+}
+
+func TestManager_EffectiveConfigName(t *testing.T) {
+	m := &manager{config: Config{Name: "option"}}
+	WithName("instance-2")(m)
+	c, err := m.effectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "instance-2" {
+		t.Errorf("exp: %s, got: %s", "instance-2", c.Name)
+	}
+}
+
+func TestManager_PrintConfigJSON(t *testing.T) {
+	m := &manager{timeout: time.Second}
+	var buf bytes.Buffer
+	c := Config{Name: "test", Password: "secret", Parameters: map[string]interface{}{"token": Secret("secret")}}
+	if err := m.printConfig(&buf, c, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["name"] != "test" || got["timeout_stop"] != "1s" {
+		t.Errorf("exp: name and timeout, got: %v", got)
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Errorf("exp: password is not printed")
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		v   string
+		exp time.Duration
+	}{
+		{"30", time.Second * 30},
+		{"1m30s", time.Second * 90},
+		{" 500ms ", time.Millisecond * 500},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeout(tt.v)
+		if err != nil || got != tt.exp {
+			t.Errorf("%q exp: %v, got: %v %v", tt.v, tt.exp, got, err)
+		}
+	}
+
+	for _, v := range []string{"", "abc", "-5s"} {
+		if _, err := parseTimeout(v); err == nil {
+			t.Errorf("exp: error of %q", v)
+		}
+	}
+}
+
+func TestManager_OverrideTimeouts(t *testing.T) {
+	os.Setenv(EnvTimeoutStop, "45")
+	defer os.Unsetenv(EnvTimeoutStop)
+
+	m := newManager(nil, TimeoutStop(time.Second*5), TimeoutShutdown(time.Second*3))
+	m.overrideTimeouts("")
+	if m.timeout != time.Second*45 || m.timeoutShutdown != time.Second*3 {
+		t.Errorf("exp: overridden timeout of stop, got: %v %v", m.timeout, m.timeoutShutdown)
+	}
+}
+
+func TestManager_ApplyConfig(t *testing.T) {
+	m := newManager(nil, TimeoutStop(time.Second*5), TimeoutShutdown(time.Second*3), WithConfig(Config{TimeoutStop: 60, LogLevel: "warning"}))
+	c, err := m.effectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.applyConfig(c)
+	if m.timeout != time.Minute || m.timeoutShutdown != time.Second*3 {
+		t.Errorf("exp: timeout of stop from config, got: %v %v", m.timeout, m.timeoutShutdown)
+	}
+	if m.logLevel != LevelWarning {
+		t.Errorf("exp: %v, got: %v", LevelWarning, m.logLevel)
+	}
+}
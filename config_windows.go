@@ -0,0 +1,216 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// WithConfig is a option to specify configuration of the service.
+// Not empty fields override values of the previous options.
+func WithConfig(c Config) option {
+	return func(m *manager) {
+		m.config = m.config.merge(c)
+	}
+}
+
+// WithConfigFile is a option to specify json file of configuration.
+// Values of the file override values of WithConfig.
+// The file is not required, it is skipped if it does not exist.
+func WithConfigFile(path string) option {
+	return func(m *manager) {
+		m.configFile = path
+	}
+}
+
+// WithName is a option to set name of the service, it overrides name of the configuration,
+// so one executable which is installed under different names selects configuration of the instance.
+// Flag of action "run -name <name>" overrides it.
+func WithName(name string) option {
+	return func(m *manager) {
+		m.instance = name
+	}
+}
+
+// ImportConfig reads configuration of the installed service,
+// so service created without winsvc can be managed by it.
+// Password can not be read and it is always empty.
+func ImportConfig(name string) (Config, error) {
+	var c Config
+	err := withService(context.Background(), name, func(s *mgr.Service) (err error) {
+		c, err = importConfig(s, name)
+		return err
+	})
+	return c, wrapError("import", name, err)
+}
+
+// importConfig reads configuration of the opened service.
+func importConfig(s *mgr.Service, name string) (Config, error) {
+	sc, err := s.Config()
+	if err != nil {
+		return Config{}, err
+	}
+
+	args, err := windows.DecomposeCommandLine(sc.BinaryPathName)
+	if err != nil {
+		return Config{}, err
+	}
+
+	c := Config{
+		Name:             name,
+		DisplayName:      sc.DisplayName,
+		Description:      sc.Description,
+		StartType:        sc.StartType,
+		DelayedAutoStart: sc.DelayedAutoStart,
+		Dependencies:     sc.Dependencies,
+		Account:          sc.ServiceStartName,
+	}
+
+	if len(args) > 0 {
+		c.Executable = args[0]
+		c.Args = args[1:]
+	}
+	return c, importRecovery(s, &c)
+}
+
+// importRecovery reads recovery actions of the service to the configuration.
+func importRecovery(s *mgr.Service, c *Config) error {
+	actions, err := s.RecoveryActions()
+	if err != nil {
+		return err
+	}
+
+	for _, a := range actions {
+		c.Recovery = append(c.Recovery, RecoveryAction{Type: RecoveryType(a.Type), Delay: int(a.Delay / time.Second)})
+	}
+
+	reset, err := s.ResetPeriod()
+	if err != nil {
+		return err
+	}
+	c.RecoveryReset = int(reset)
+
+	c.RecoveryCommand, err = s.RecoveryCommand()
+	return err
+}
+
+// effectiveConfig returns configuration merged from options, file and environment variables.
+func (m *manager) effectiveConfig() (Config, error) {
+	path := m.configFile
+	if env := os.Getenv(EnvConfigFile); env != "" {
+		path = env
+	}
+
+	file, err := configFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	c := m.config.merge(file).merge(configEnv())
+	if m.instance != "" {
+		c.Name = m.instance
+	}
+
+	if c.Executable == "" {
+		if c.Executable, err = os.Executable(); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(c.Executable), filepath.Ext(c.Executable))
+	}
+
+	if c.StartType == 0 {
+		c.StartType = StartAutomatic
+	}
+	c.Description = m.describeVersion(c.Description)
+	return c, nil
+}
+
+// applyConfig sets timeouts of stop and level of event log by the configuration, zero values keep options.
+func (m *manager) applyConfig(c Config) {
+	for _, t := range []struct {
+		seconds int
+		timeout *time.Duration
+	}{
+		{c.TimeoutStop, &m.timeout},
+		{c.TimeoutShutdown, &m.timeoutShutdown},
+		{c.TimeoutPreShutdown, &m.timeoutPreShutdown},
+	} {
+		if t.seconds > 0 {
+			*t.timeout = time.Duration(t.seconds) * time.Second
+		}
+	}
+
+	if l, err := parseLevel(c.LogLevel); err == nil {
+		m.logLevel = l
+	}
+}
+
+// printConfig prints the configuration of service in human or json form.
+func (m *manager) printConfig(w io.Writer, c Config, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Config
+			TimeoutStop  string `json:"timeout_stop"`
+			DisablePanic bool   `json:"disable_panic"`
+		}{c, m.timeout.String(), m.disablePanic})
+	}
+
+	start := startTypeString(c.StartType)
+	if c.DelayedAutoStart {
+		start += " (delayed)"
+	}
+
+	account := c.Account
+	if account == "" {
+		account = "LocalSystem"
+	}
+
+	_, err := fmt.Fprintf(w, "Name:          %s\n"+
+		"Display name:  %s\n"+
+		"Description:   %s\n"+
+		"Start type:    %s\n"+
+		"Dependencies:  %s\n"+
+		"Account:       %s\n"+
+		"Executable:    %s\n"+
+		"Arguments:     %s\n"+
+		"Timeout stop:  %s\n"+
+		"Disable panic: %t\n",
+		c.Name, c.DisplayName, c.Description, start, strings.Join(c.Dependencies, ", "),
+		account, c.Executable, strings.Join(c.Args, " "), m.timeout, m.disablePanic)
+	if err != nil || len(c.Parameters) == 0 {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Parameters))
+	for k := range c.Parameters {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	if _, err := fmt.Fprintln(w, "Parameters:"); err != nil {
+		return err
+	}
+
+	for _, k := range names {
+		if _, err := fmt.Fprintf(w, "  %s = %v\n", k, c.Parameters[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// +build windows
+
+package winsvc
+
+import "syscall"
+
+// Events of console control handler.
+const (
+	ctrlCloseEvent    = 2
+	ctrlLogoffEvent   = 5
+	ctrlShutdownEvent = 6
+)
+
+var procSetConsoleCtrlHandler = modkernel32.NewProc("SetConsoleCtrlHandler")
+
+// handleConsoleClose registers console control handler which stops the service gracefully
+// when the console window is closed, the user logs off or the system shuts down (unless IgnoreShutdown is set).
+// Windows terminates the process when handler returns, so it waits until the service is stopped,
+// but Windows does not wait longer than about 5 seconds after closing of the console.
+// It returns function which unregisters the handler.
+func (m *manager) handleConsoleClose() (func(), error) {
+	handler := syscall.NewCallback(func(event uint32) uintptr {
+		switch event {
+		case ctrlCloseEvent, ctrlLogoffEvent:
+		case ctrlShutdownEvent:
+			if m.ignoreShutdown {
+				return 0 // default handler terminates the process
+			}
+		default:
+			return 0 // Ctrl+C and Ctrl+Break are handled by signals
+		}
+
+		m.stopOnce.Do(func() { close(m.stopReq) })
+		<-m.done
+		return 1
+	})
+
+	if r, _, err := procSetConsoleCtrlHandler.Call(handler, 1); r == 0 {
+		return nil, err
+	}
+	return func() { procSetConsoleCtrlHandler.Call(handler, 0) }, nil
+}
@@ -0,0 +1,58 @@
+// +build windows
+
+package winsvc
+
+import "golang.org/x/sys/windows/svc"
+
+// ControlHandler handles control of the service manager.
+type ControlHandler func(c svc.ChangeRequest)
+
+// UseControl is a option to wrap handler of controls of the service manager with middleware,
+// for example for logging, metrics or policy. Control is refused if middleware does not call next,
+// so stop can be refused during critical batch and the service keeps running.
+// The first middleware is the outermost.
+//
+//	winsvc.UseControl(func(next winsvc.ControlHandler) winsvc.ControlHandler {
+//		return func(c svc.ChangeRequest) {
+//			log.Printf("[INFO] control %d", c.Cmd)
+//			next(c)
+//		}
+//	})
+func UseControl(mw func(next ControlHandler) ControlHandler) option {
+	return func(m *manager) {
+		m.controlMW = append(m.controlMW, mw)
+	}
+}
+
+// controlHandler returns handler of controls which is wrapped by middlewares.
+func (m *manager) controlHandler(h ControlHandler) ControlHandler {
+	for i := len(m.controlMW) - 1; i >= 0; i-- {
+		h = m.controlMW[i](h)
+	}
+	return h
+}
+
+// RawControls returns channel of controls of the service manager for applications which handle them themselves,
+// every control besides interrogate is sent to the channel instead of handling by the package. Status is still reported
+// by the package, the service is stopped by StopAsync. The channel is closed when the service is stopped.
+// EventData of controls is zero, memory of the service manager is not valid after its handler has returned.
+// RawControls must be called before Run.
+func (h *Handle) RawControls() <-chan svc.ChangeRequest {
+	if h.m.rawControls == nil {
+		h.m.rawControls = make(chan svc.ChangeRequest)
+	}
+	return h.m.rawControls
+}
+
+// sendRaw sends control to the application, it reports false if controls are handled by the package.
+func (m *manager) sendRaw(c svc.ChangeRequest) bool {
+	if m.rawControls == nil || c.Cmd == svc.Interrogate {
+		return false
+	}
+
+	select {
+	case m.rawControls <- c:
+	case <-m.stopReq:
+	}
+	return true
+}
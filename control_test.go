@@ -0,0 +1,75 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestUseControl(t *testing.T) {
+	var (
+		got     []svc.Cmd
+		refused bool
+	)
+	log := func(next ControlHandler) ControlHandler {
+		return func(c svc.ChangeRequest) {
+			got = append(got, c.Cmd)
+			next(c)
+		}
+	}
+	refuseStop := func(next ControlHandler) ControlHandler {
+		return func(c svc.ChangeRequest) {
+			if c.Cmd == svc.Stop && !refused {
+				refused = true
+				return
+			}
+			next(c)
+		}
+	}
+
+	m := newManager(func(ctx context.Context) { <-ctx.Done() }, UseControl(log), UseControl(refuseStop))
+	states := controlScript(t, m, svc.Stop, svc.Interrogate, svc.Stop)
+
+	exp := []svc.Cmd{svc.Stop, svc.Interrogate, svc.Stop}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	expStates := []svc.State{svc.StartPending, svc.Running, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(states, expStates) {
+		t.Errorf("exp: %v, got: %v", expStates, states)
+	}
+}
+
+func TestHandle_RawControls(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	raw := h.RawControls()
+
+	var got []svc.Cmd
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		for c := range raw {
+			got = append(got, c.Cmd)
+			if c.Cmd == svc.Stop {
+				h.StopAsync()
+			}
+		}
+	}()
+
+	states := controlScript(t, h.m, svc.Pause, svc.Interrogate, svc.Stop)
+	<-read
+	exp := []svc.Cmd{svc.Pause, svc.Stop}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	expStates := []svc.State{svc.StartPending, svc.Running, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(states, expStates) {
+		t.Errorf("exp: %v, got: %v", expStates, states)
+	}
+}
@@ -0,0 +1,87 @@
+// +build windows
+
+package winsvc
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// localDumpsKey is a registry key of settings of crash dumps of Windows Error Reporting.
+	localDumpsKey = `SOFTWARE\Microsoft\Windows\Windows Error Reporting\LocalDumps\`
+	// crashDumpsValue is a value of registry key of the service with key of crash dumps which has been created at install.
+	crashDumpsValue = "WinsvcCrashDumps"
+
+	defaultCrashDumpCount = 10
+	dumpTypeFull          = 2
+)
+
+// registerCrashDumps registers the executable in Windows Error Reporting, so full dumps of native crashes
+// (which are not caught by recover) are written to Config.CrashDumpDir. Existing settings of the executable are kept.
+func registerCrashDumps(c Config, exe string) error {
+	if c.CrashDumpDir == "" {
+		return nil
+	}
+
+	key := crashDumpKey(exe)
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, key, registry.QUERY_VALUE); err == nil {
+		k.Close()
+		return nil
+	}
+
+	dir, err := registry.ExpandString(c.CrashDumpDir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, key, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	if err := trackArtifacts(c.Name, crashDumpsValue, []string{key}); err != nil {
+		return err
+	}
+
+	count := c.CrashDumpCount
+	if count == 0 {
+		count = defaultCrashDumpCount
+	}
+
+	if err := k.SetExpandStringValue("DumpFolder", c.CrashDumpDir); err != nil {
+		return err
+	}
+
+	if err := k.SetDWordValue("DumpCount", uint32(count)); err != nil {
+		return err
+	}
+	return k.SetDWordValue("DumpType", dumpTypeFull)
+}
+
+// crashDumpKey returns registry key of crash dumps of the executable, WER matches it by name of the file.
+func crashDumpKey(exe string) string {
+	return localDumpsKey + filepath.Base(exe)
+}
+
+// unregisterCrashDumps removes settings of crash dumps which have been created at install, dumps are kept.
+func unregisterCrashDumps(name string) error {
+	return deleteCrashDumps(trackedArtifacts(name, crashDumpsValue))
+}
+
+// deleteCrashDumps deletes keys of settings of crash dumps.
+func deleteCrashDumps(keys []string) error {
+	for _, key := range keys {
+		if err := registry.DeleteKey(registry.LOCAL_MACHINE, key); err != nil && err != registry.ErrNotExist {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+// +build windows
+
+package winsvc
+
+import "testing"
+
+func TestCrashDumpKey(t *testing.T) {
+	exp := `SOFTWARE\Microsoft\Windows\Windows Error Reporting\LocalDumps\app.exe`
+	if got := crashDumpKey(`C:\Program Files\app\app.exe`); got != exp {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
@@ -0,0 +1,82 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"time"
+)
+
+// crashLoopValue is a value of registry key Parameters of the service with times of the recent starts.
+const crashLoopValue = "WinsvcStarts"
+
+// WithCrashLoopDetection is a option to detect flapping service which is restarted by recovery actions again and again.
+// Times of starts are kept in registry key Parameters of the service, warning is written to event log
+// when the service has started count times during window and error when it has started twice as much,
+// so monitoring catches crash loop instead of just seeing running service.
+func WithCrashLoopDetection(window time.Duration, count int) option {
+	return func(m *manager) {
+		m.crashLoop = crashLoop{window: window, count: count}
+	}
+}
+
+// crashLoop is a threshold of starts of the service during window.
+type crashLoop struct {
+	window time.Duration
+	count  int
+}
+
+// checkCrashLoop records start of the service and reports crash loop.
+func (m *manager) checkCrashLoop() {
+	if m.crashLoop.count <= 0 || m.name == "" {
+		return
+	}
+
+	p, err := OpenParameters(m.name, true)
+	if err != nil {
+		return
+	}
+	defer p.Close()
+
+	stored, _ := p.Strings(crashLoopValue)
+	starts := m.crashLoop.record(stored, m.clock.Now())
+	if err := p.SetStrings(crashLoopValue, starts); err != nil {
+		return
+	}
+
+	level, ok := m.crashLoop.level(len(starts))
+	if !ok {
+		return
+	}
+	m.report(level, codeCrashLoop, fmt.Sprintf("service has started %d times during %s, it is crash-looping", len(starts), m.crashLoop.window))
+}
+
+// record returns times of starts during window before now with now, the oldest starts are dropped
+// when there are more than twice of count.
+func (l crashLoop) record(stored []string, now time.Time) []string {
+	starts := make([]string, 0, len(stored)+1)
+	for _, s := range stored {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil || now.Sub(t) > l.window || t.After(now) {
+			continue
+		}
+		starts = append(starts, s)
+	}
+	starts = append(starts, now.UTC().Format(time.RFC3339))
+
+	if max := l.count * 2; len(starts) > max {
+		starts = starts[len(starts)-max:]
+	}
+	return starts
+}
+
+// level returns level of event by count of starts, escalation is error when count is doubled.
+func (l crashLoop) level(starts int) (Level, bool) {
+	switch {
+	case starts >= l.count*2:
+		return LevelError, true
+	case starts >= l.count:
+		return LevelWarning, true
+	}
+	return 0, false
+}
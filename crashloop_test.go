@@ -0,0 +1,52 @@
+// +build windows
+
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrashLoop_Record(t *testing.T) {
+	l := crashLoop{window: time.Hour, count: 2}
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	stored := []string{
+		"2021-03-10T10:00:00Z", // out of window
+		"invalid",
+		"2021-03-10T11:10:00Z",
+		"2021-03-10T11:20:00Z",
+		"2021-03-10T11:30:00Z",
+		"2021-03-10T11:40:00Z",
+	}
+
+	got := l.record(stored, now)
+	exp := []string{"2021-03-10T11:20:00Z", "2021-03-10T11:30:00Z", "2021-03-10T11:40:00Z", "2021-03-10T12:00:00Z"}
+	if len(got) != len(exp) {
+		t.Fatalf("exp: %v, got: %v", exp, got)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("exp: %v, got: %v", exp, got)
+		}
+	}
+}
+
+func TestCrashLoop_Level(t *testing.T) {
+	l := crashLoop{window: time.Hour, count: 3}
+	tests := []struct {
+		starts int
+		exp    Level
+		ok     bool
+	}{
+		{1, 0, false},
+		{3, LevelWarning, true},
+		{5, LevelWarning, true},
+		{6, LevelError, true},
+	}
+
+	for _, tt := range tests {
+		if got, ok := l.level(tt.starts); got != tt.exp || ok != tt.ok {
+			t.Errorf("%d exp: %v %v, got: %v %v", tt.starts, tt.exp, tt.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// +build windows
+
+package winsvc
+
+import (
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	modadvapi32     = windows.NewLazySystemDLL("advapi32.dll")
+	procCredWriteW  = modadvapi32.NewProc("CredWriteW")
+	procCredReadW   = modadvapi32.NewProc("CredReadW")
+	procCredDeleteW = modadvapi32.NewProc("CredDeleteW")
+	procCredFree    = modadvapi32.NewProc("CredFree")
+)
+
+// credential is CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// StoreCredential stores user and secret as generic credential of Windows Credential Manager
+// of the current user, it is the same as "cmdkey /generic:target /user:user /pass:secret".
+func StoreCredential(target, user string, secret Secret) error {
+	runes := []rune(string(secret))
+	blob := utf16.Encode(runes)
+	wipeRunes(runes)
+	defer wipeUint16(blob)
+
+	c := credential{
+		Type:       credTypeGeneric,
+		TargetName: windows.StringToUTF16Ptr(target),
+		UserName:   windows.StringToUTF16Ptr(user),
+		Persist:    credPersistLocalMachine,
+	}
+
+	if len(blob) > 0 {
+		c.CredentialBlobSize = uint32(len(blob) * 2)
+		c.CredentialBlob = (*byte)(unsafe.Pointer(&blob[0]))
+	}
+
+	r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&c)), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// ReadCredential returns user and secret of generic credential of Windows Credential Manager.
+// Credentials are stored per user, so the service reads only credentials of its account.
+func ReadCredential(target string) (string, Secret, error) {
+	var (
+		user   string
+		secret Secret
+	)
+	err := readCredential(target, func(u string, blob []uint16) {
+		user, secret = u, Secret(utf16.Decode(blob))
+	})
+	return user, secret, err
+}
+
+// readCredential calls f with user and secret of generic credential, blob is valid only inside f,
+// it is wiped before memory of the credential is freed.
+func readCredential(target string, f func(user string, blob []uint16)) error {
+	var c *credential
+	r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(target))),
+		credTypeGeneric, 0, uintptr(unsafe.Pointer(&c)))
+	if r == 0 {
+		return err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(c)))
+
+	var blob []uint16
+	if n := c.CredentialBlobSize / 2; n > 0 {
+		blob = (*[1 << 29]uint16)(unsafe.Pointer(c.CredentialBlob))[:n:n]
+	}
+	f(windows.UTF16PtrToString(c.UserName), blob)
+	wipeUint16(blob)
+	return nil
+}
+
+// DeleteCredential deletes generic credential of Windows Credential Manager.
+func DeleteCredential(target string) error {
+	r, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(target))), credTypeGeneric, 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// +build windows
+
+package winsvc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// dataDirsValue is a value of registry key of the service with directories which have been created at install.
+const dataDirsValue = "WinsvcDataDirs"
+
+// createDataDirs creates not existed directories of data, environment variables like %ProgramData% are expanded.
+// Only created directories are removed on uninstall.
+func createDataDirs(name string, dirs []string) error {
+	var created []string
+	for _, dir := range dirs {
+		path, err := registry.ExpandString(dir)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+		created = append(created, path)
+	}
+
+	if len(created) == 0 {
+		return nil
+	}
+	return trackArtifacts(name, dataDirsValue, append(trackedArtifacts(name, dataDirsValue), created...))
+}
+
+// removeDataDirs removes directories of data which have been created at install.
+func removeDataDirs(name string) error {
+	return deleteDataDirs(trackedArtifacts(name, dataDirsValue))
+}
+
+// deleteDataDirs removes directories with their content.
+func deleteDataDirs(dirs []string) error {
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// programDataSubdirs are directories of the service in ProgramData.
+var programDataSubdirs = []string{"logs", "data", "config"}
+
+// ProgramDataDir returns directory of the service in ProgramData, subdirectories logs, data and config
+// are created at install if Config.ProgramData is set.
+func ProgramDataDir(name string) string {
+	root := os.Getenv("ProgramData")
+	if root == "" {
+		root = `C:\ProgramData`
+	}
+	return filepath.Join(root, name)
+}
+
+// createProgramData creates directory of the service in ProgramData with subdirectories.
+// Access is not inherited from ProgramData (users can create files there): SYSTEM and administrators have full control,
+// the account and SID of the service can modify.
+func createProgramData(c Config) error {
+	if !c.ProgramData {
+		return nil
+	}
+
+	root := ProgramDataDir(c.Name)
+	dirs := []string{root}
+	for _, d := range programDataSubdirs {
+		dirs = append(dirs, filepath.Join(root, d))
+	}
+
+	if err := createDataDirs(c.Name, dirs); err != nil {
+		return err
+	}
+	return secureDir(root, c.Name, c.Account)
+}
+
+// secureDir sets protected access of the directory: full control of SYSTEM and administrators,
+// modify of the account and SID of the service (it is needed when SID type of the service is restricted, see HardeningStrict).
+func secureDir(path, name, account string) error {
+	aces, err := serviceACEs(name, account, "OICI", "0x1301bf")
+	if err != nil {
+		return err
+	}
+	return setDACL(path, windows.SE_FILE_OBJECT, "D:P(A;OICI;FA;;;SY)(A;OICI;FA;;;BA)"+aces)
+}
+
+// isLocalSystem reports whether account is LocalSystem.
+func isLocalSystem(account string) bool {
+	return account == "" || strings.EqualFold(account, "LocalSystem") || strings.EqualFold(account, `NT AUTHORITY\System`)
+}
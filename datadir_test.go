@@ -0,0 +1,18 @@
+// +build windows
+
+package winsvc
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProgramDataDir(t *testing.T) {
+	old := os.Getenv("ProgramData")
+	os.Setenv("ProgramData", `D:\ProgramData`)
+	defer os.Setenv("ProgramData", old)
+
+	if got, exp := ProgramDataDir("gowinsvc"), `D:\ProgramData\gowinsvc`; got != exp {
+		t.Errorf("exp: %s, got: %s", exp, got)
+	}
+}
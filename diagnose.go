@@ -0,0 +1,165 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	policyLookupNames       = 0x00000800
+	statusObjectNameMissing = 0xC0000034 // STATUS_OBJECT_NAME_NOT_FOUND, account has no rights
+	serviceLogonRight       = "SeServiceLogonRight"
+)
+
+var (
+	procLsaOpenPolicy             = modadvapi32.NewProc("LsaOpenPolicy")
+	procLsaEnumerateAccountRights = modadvapi32.NewProc("LsaEnumerateAccountRights")
+	procLsaFreeMemory             = modadvapi32.NewProc("LsaFreeMemory")
+	procLsaClose                  = modadvapi32.NewProc("LsaClose")
+	procLsaNtStatusToWinError     = modadvapi32.NewProc("LsaNtStatusToWinError")
+)
+
+// lsaUnicodeString is LSA_UNICODE_STRING structure.
+type lsaUnicodeString struct {
+	Length        uint16 // in bytes
+	MaximumLength uint16
+	Buffer        *uint16
+}
+
+// lsaObjectAttributes is LSA_OBJECT_ATTRIBUTES structure.
+type lsaObjectAttributes struct {
+	Length                   uint32
+	RootDirectory            uintptr
+	ObjectName               uintptr
+	Attributes               uint32
+	SecurityDescriptor       uintptr
+	SecurityQualityOfService uintptr
+}
+
+// Diagnosis is a result of diagnostics of environment of the service.
+type Diagnosis struct {
+	Elevated     bool  // the process is elevated
+	SCMReachable bool  // the service manager is connected
+	SCMError     error // error of connection to the service manager
+	Installed    bool
+	Account      string // account of the installed service or of configuration
+	LogonRight   bool   // account has SeServiceLogonRight, built-in accounts have it always
+	LogonError   error  // error of checking of the right
+	ImagePath    string // command line of the installed service
+	PathQuoted   bool   // executable of command line is quoted or has no spaces
+}
+
+// Diagnose checks elevation of the process, connection to the service manager, logon right of the account
+// and quoting of command line of the service. Account and command line of the installed service are checked,
+// account of the configuration is checked if the service is not installed.
+func Diagnose(c Config) Diagnosis {
+	d := Diagnosis{Elevated: windows.GetCurrentProcessToken().IsElevated(), Account: c.Account, PathQuoted: true}
+
+	m, err := mgr.Connect()
+	d.SCMReachable, d.SCMError = err == nil, err
+	if err == nil {
+		defer m.Disconnect()
+		if s, err := m.OpenService(c.Name); err == nil {
+			d.Installed = true
+			if sc, err := s.Config(); err == nil {
+				d.Account = sc.ServiceStartName
+			}
+			s.Close()
+		}
+	}
+
+	if d.Installed {
+		if path, err := rawImagePath(c.Name); err == nil {
+			d.ImagePath, d.PathQuoted = path, pathQuoted(path)
+		}
+	}
+
+	d.LogonRight, d.LogonError = hasServiceLogonRight(d.Account)
+	return d
+}
+
+// ServicePath is a command line of the service.
+type ServicePath struct {
+	Name      string
+	ImagePath string
+}
+
+// UnquotedServicePaths returns services of the system which have executable path with spaces without quotes,
+// it is a well-known vector of privilege escalation.
+func UnquotedServicePaths() ([]ServicePath, error) {
+	m, err := connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer m.Disconnect()
+
+	services, err := enumServices(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []ServicePath
+	for _, s := range services {
+		if path, err := rawImagePath(s.Name); err == nil && !pathQuoted(path) {
+			list = append(list, ServicePath{Name: s.Name, ImagePath: path})
+		}
+	}
+	return list, nil
+}
+
+// hasServiceLogonRight reports whether account has right to log on as service.
+func hasServiceLogonRight(account string) (bool, error) {
+	if builtinAccount(account) {
+		return true, nil
+	}
+
+	sid, _, _, err := windows.LookupSID("", strings.TrimPrefix(account, `.\`))
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		attrs  = lsaObjectAttributes{}
+		policy windows.Handle
+	)
+	attrs.Length = uint32(unsafe.Sizeof(attrs))
+	if st, _, _ := procLsaOpenPolicy.Call(0, uintptr(unsafe.Pointer(&attrs)), policyLookupNames, uintptr(unsafe.Pointer(&policy))); st != 0 {
+		return false, ntStatusError(st)
+	}
+	defer procLsaClose.Call(uintptr(policy))
+
+	var (
+		rights *lsaUnicodeString
+		count  uint32
+	)
+	st, _, _ := procLsaEnumerateAccountRights.Call(uintptr(policy), uintptr(unsafe.Pointer(sid)),
+		uintptr(unsafe.Pointer(&rights)), uintptr(unsafe.Pointer(&count)))
+	if st == statusObjectNameMissing {
+		return false, nil
+	}
+	if st != 0 {
+		return false, ntStatusError(st)
+	}
+	defer procLsaFreeMemory.Call(uintptr(unsafe.Pointer(rights)))
+
+	list := (*[1 << 16]lsaUnicodeString)(unsafe.Pointer(rights))[:count:count]
+	for _, r := range list {
+		name := windows.UTF16ToString((*[1 << 16]uint16)(unsafe.Pointer(r.Buffer))[: r.Length/2 : r.Length/2])
+		if strings.EqualFold(name, serviceLogonRight) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ntStatusError returns windows error of NTSTATUS.
+func ntStatusError(st uintptr) error {
+	code, _, _ := procLsaNtStatusToWinError.Call(st)
+	return windows.Errno(code)
+}
@@ -0,0 +1,25 @@
+// +build windows
+
+package winsvc
+
+import "testing"
+
+func TestBuiltinAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		exp     bool
+	}{
+		{"", true},
+		{"LocalSystem", true},
+		{`NT AUTHORITY\NetworkService`, true},
+		{`NT SERVICE\gowinsvc`, true},
+		{`DOMAIN\svc-gmsa$`, true},
+		{`.\user`, false},
+	}
+
+	for _, tt := range tests {
+		if got := builtinAccount(tt.account); got != tt.exp {
+			t.Errorf("%s exp: %t, got: %t", tt.account, tt.exp, got)
+		}
+	}
+}
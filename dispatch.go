@@ -0,0 +1,28 @@
+// +build windows
+
+package winsvc
+
+import "strings"
+
+// RegisterService is a option to run function r when the service is started by the service manager as name,
+// so one executable backs several installed services. Run function of Run is used for other names, it can be nil.
+// In interactive mode the name is set by flag --name.
+//
+//	winsvc.Run(nil, winsvc.RegisterService("app-api", runAPI), winsvc.RegisterService("app-worker", runWorker))
+func RegisterService(name string, r runFunc) option {
+	return func(m *manager) {
+		if m.services == nil {
+			m.services = make(map[string]runFunc)
+		}
+		m.services[strings.ToLower(name)] = r
+	}
+}
+
+// dispatch selects run function by name of the service, it returns false if there is no run function of the name.
+func (m *manager) dispatch(name string) bool {
+	if r, ok := m.services[strings.ToLower(name)]; ok {
+		m.svcHandler = r
+		m.name = name
+	}
+	return m.svcHandler != nil
+}
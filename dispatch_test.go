@@ -0,0 +1,38 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestManager_Dispatch(t *testing.T) {
+	var got string
+	m := newManager(nil,
+		RegisterService("app-api", func(ctx context.Context) { got = "api" }),
+		RegisterService("app-worker", func(ctx context.Context) { got = "worker" }))
+
+	if !m.dispatch("APP-Worker") {
+		t.Fatal("exp: run function of app-worker")
+	}
+	m.svcHandler(context.Background())
+	if got != "worker" || m.name != "APP-Worker" {
+		t.Errorf("exp: worker, got: %s %s", got, m.name)
+	}
+
+	if m := newManager(nil, RegisterService("app-api", nil)); m.dispatch("other") {
+		t.Errorf("exp: no run function of other")
+	}
+}
+
+func TestExecute_NotInExe(t *testing.T) {
+	m := newManager(nil, RegisterService("app-api", func(ctx context.Context) { <-ctx.Done() }))
+	_, code := m.Execute([]string{"other"}, make(chan svc.ChangeRequest), make(chan svc.Status, 10))
+	if code != uint32(windows.ERROR_SERVICE_NOT_IN_EXE) {
+		t.Errorf("exp: %d, got: %d", windows.ERROR_SERVICE_NOT_IN_EXE, code)
+	}
+}
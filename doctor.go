@@ -0,0 +1,134 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// checkResult is a result of check of action doctor.
+type checkResult struct {
+	name   string
+	ok     bool
+	detail string
+	remedy string
+}
+
+// doctor checks environment of the service and the installed service.
+func doctor(c Config) []checkResult {
+	d := Diagnose(c)
+	checks := []checkResult{
+		{name: "elevated", ok: d.Elevated, remedy: "run the command as administrator"},
+		{name: "service manager", ok: d.SCMReachable, detail: errorDetail(d.SCMError),
+			remedy: "check that the service manager is running and the account has access to it"},
+		{name: "log on as service", ok: d.LogonRight, detail: joinDetail(fmt.Sprintf("account %q", accountOrDefault(d.Account)), errorDetail(d.LogonError)),
+			remedy: `grant "Log on as a service" right to the account (secpol.msc) or use virtual account`},
+	}
+
+	if !d.SCMReachable {
+		return checks
+	}
+
+	if unquoted, err := UnquotedServicePaths(); err == nil {
+		checks = append(checks, checkResult{name: "no unquoted paths of services", ok: len(unquoted) == 0, detail: unquotedDetail(unquoted),
+			remedy: "quote executable in ImagePath of the services, unquoted path with spaces allows privilege escalation"})
+	}
+
+	if !d.Installed {
+		return append(checks, checkResult{name: "installed", remedy: "run action install"})
+	}
+
+	checks = append(checks, checkResult{name: "quoted path", ok: d.PathQuoted, detail: d.ImagePath,
+		remedy: "reinstall the service, unquoted path with spaces allows privilege escalation"})
+
+	err := withService(context.Background(), c.Name, func(s *mgr.Service) error {
+		checks = append(checks, checkResult{name: "not marked for deletion", ok: checkDeletion(s) == nil,
+			remedy: "close services.msc and programs which hold the service or restart the system"})
+
+		checks = append(checks, checkResult{name: "event log source", ok: eventSourceExists(c.Name),
+			remedy: "reinstall the service to register source of event log"})
+
+		if st, err := ReadStatus(c.Name); err == nil {
+			status, err := s.Query()
+			stale := err == nil && (status.State != svc.Running || int(status.ProcessId) != st.PID)
+			checks = append(checks, checkResult{name: "status is not stale", ok: !stale, detail: fmt.Sprintf("PID %d", st.PID),
+				remedy: "the process has crashed, start the service or delete registry key " + servicesKey + c.Name + statusSubkey})
+		}
+
+		actual, err := s.RecoveryActions()
+		checks = append(checks, checkResult{name: "recovery actions", ok: err == nil && sameRecovery(actual, recoveryActions(c)),
+			detail: errorDetail(err), remedy: "reinstall the service to apply recovery actions of the configuration"})
+		return nil
+	})
+	if err != nil {
+		checks = append(checks, checkResult{name: "open service", detail: errorDetail(err), remedy: "run the command as administrator"})
+	}
+	return checks
+}
+
+// unquotedDetail returns names of services with unquoted paths.
+func unquotedDetail(list []ServicePath) string {
+	names := make([]string, 0, len(list))
+	for _, p := range list {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// eventSourceExists reports whether source of Application event log is registered.
+func eventSourceExists(source string) bool {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, eventLogKey+`\`+source, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	k.Close()
+	return true
+}
+
+// errorDetail returns detail of check by error.
+func errorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// joinDetail joins not empty details.
+func joinDetail(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + ", " + b
+}
+
+// printChecks prints results of checks with remedies of failed checks and returns count of failed checks.
+func printChecks(w io.Writer, checks []checkResult) (int, error) {
+	failed := 0
+	for _, c := range checks {
+		status := "OK"
+		if !c.ok {
+			status = "FAIL"
+			failed++
+		}
+
+		line := fmt.Sprintf("[%-4s] %s", status, c.name)
+		if c.detail != "" {
+			line += " (" + c.detail + ")"
+		}
+		if !c.ok {
+			line += "\n       hint: " + c.remedy
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return failed, err
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,28 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintChecks(t *testing.T) {
+	var buf bytes.Buffer
+	failed, err := printChecks(&buf, []checkResult{
+		{name: "elevated", ok: true},
+		{name: "quoted path", detail: `C:\Program Files\app.exe`, remedy: "reinstall the service"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failed != 1 {
+		t.Errorf("exp: %d, got: %d", 1, failed)
+	}
+
+	exp := "[OK  ] elevated\n[FAIL] quoted path (C:\\Program Files\\app.exe)\n       hint: reinstall the service\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("exp: %q, got: %q", exp, got)
+	}
+}
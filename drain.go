@@ -0,0 +1,66 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// drain calls drain hooks and reports their progress as checkpoints of stop pending state.
+func (m *manager) drain(status *statusReporter, timeout time.Duration) {
+	if len(m.onDrain) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := m.clock.Now()
+
+	var (
+		mu         sync.Mutex
+		checkPoint uint32
+		finished   bool
+	)
+	report := func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// hook can report from its goroutines after it has returned
+		if status == nil || finished {
+			return
+		}
+
+		checkPoint++
+		hint := drainHint(m.clock.Now().Sub(start), timeout, done, total)
+		status.set(svc.Status{State: svc.StopPending, CheckPoint: checkPoint, WaitHint: waitHint(hint)})
+	}
+
+	for _, f := range m.onDrain {
+		f(ctx, report)
+	}
+
+	mu.Lock()
+	finished = true
+	mu.Unlock()
+}
+
+// drainHint returns expected time of the remaining work by average time of the done work,
+// it is not more than the remaining timeout. Remaining timeout is returned until progress is known.
+func drainHint(elapsed, timeout time.Duration, done, total int) time.Duration {
+	left := timeout - elapsed
+	if left < 0 {
+		return 0
+	}
+	if done <= 0 || done >= total {
+		return left
+	}
+
+	if hint := elapsed / time.Duration(done) * time.Duration(total-done); hint < left {
+		return hint
+	}
+	return left
+}
@@ -0,0 +1,64 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestDrain_CheckPoints(t *testing.T) {
+	c := newFakeClock()
+	m := newManager(func(ctx context.Context) { <-ctx.Done() })
+	m.clock = c
+	m.onDrain = append(m.onDrain, func(ctx context.Context, report func(done, total int)) {
+		report(0, 4)
+		c.Advance(time.Second)
+		report(1, 4)
+		c.Advance(time.Second)
+		report(2, 4)
+	})
+
+	changes := make(chan svc.Status, 3)
+	status := newStatusReporter(changes)
+	status.current = svc.Status{State: svc.Running}
+	m.drain(status, time.Second*10)
+	close(changes)
+
+	var got []svc.Status
+	for s := range changes {
+		got = append(got, s)
+	}
+	exp := []svc.Status{
+		{State: svc.StopPending, CheckPoint: 1, WaitHint: 10000},
+		{State: svc.StopPending, CheckPoint: 2, WaitHint: 3000},
+		{State: svc.StopPending, CheckPoint: 3, WaitHint: 2000},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestDrainHint(t *testing.T) {
+	tests := []struct {
+		elapsed     time.Duration
+		done, total int
+		exp         time.Duration
+	}{
+		{0, 0, 4, time.Second * 10},
+		{time.Second, 1, 4, time.Second * 3},
+		{time.Second * 4, 1, 4, time.Second * 6},
+		{time.Second * 2, 4, 4, time.Second * 8},
+		{time.Second * 11, 1, 4, 0},
+	}
+
+	for _, tt := range tests {
+		if got := drainHint(tt.elapsed, time.Second*10, tt.done, tt.total); got != tt.exp {
+			t.Errorf("exp: %v, got: %v", tt.exp, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package winsvc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Drift is a difference of the installed configuration of the service from the desired one.
+type Drift []FieldDrift
+
+// FieldDrift is a field of configuration which differs.
+type FieldDrift struct {
+	Field     string
+	Installed string
+	Desired   string
+}
+
+// String returns human readable drift, one field per line.
+func (d Drift) String() string {
+	lines := make([]string, 0, len(d))
+	for _, f := range d {
+		lines = append(lines, fmt.Sprintf("%s: %q -> %q", f.Field, f.Installed, f.Desired))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// has reports whether the field differs.
+func (d Drift) has(field string) bool {
+	for _, f := range d {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields of configuration which are compared.
+const (
+	fieldDisplayName      = "display_name"
+	fieldDescription      = "description"
+	fieldStartType        = "start_type"
+	fieldDelayedAutoStart = "delayed_auto_start"
+	fieldDependencies     = "dependencies"
+	fieldAccount          = "account"
+	fieldExecutable       = "executable"
+	fieldArgs             = "args"
+	fieldRecovery         = "recovery"
+)
+
+// diffConfig returns fields of the installed configuration which differ from the desired one.
+// Empty fields of the desired configuration are not compared, defaults are used for start type and account.
+func diffConfig(installed, desired Config) Drift {
+	var d Drift
+	add := func(field, installed, desired string) {
+		d = append(d, FieldDrift{Field: field, Installed: installed, Desired: desired})
+	}
+
+	if desired.DisplayName != "" && desired.DisplayName != installed.DisplayName {
+		add(fieldDisplayName, installed.DisplayName, desired.DisplayName)
+	}
+
+	if desired.Description != "" && desired.Description != installed.Description {
+		add(fieldDescription, installed.Description, desired.Description)
+	}
+
+	start := desired.StartType
+	if start == 0 {
+		start = StartAutomatic
+	}
+	if start != installed.StartType {
+		add(fieldStartType, startTypeString(installed.StartType), startTypeString(start))
+	}
+
+	if desired.DelayedAutoStart != installed.DelayedAutoStart {
+		add(fieldDelayedAutoStart, fmt.Sprint(installed.DelayedAutoStart), fmt.Sprint(desired.DelayedAutoStart))
+	}
+
+	if len(desired.Dependencies) > 0 && strings.Join(desired.Dependencies, ",") != strings.Join(installed.Dependencies, ",") {
+		add(fieldDependencies, strings.Join(installed.Dependencies, ", "), strings.Join(desired.Dependencies, ", "))
+	}
+
+	if !strings.EqualFold(accountOrDefault(desired.Account), accountOrDefault(installed.Account)) {
+		add(fieldAccount, installed.Account, desired.Account)
+	}
+
+	if desired.Executable != "" && !strings.EqualFold(desired.Executable, installed.Executable) {
+		add(fieldExecutable, installed.Executable, desired.Executable)
+	}
+
+	if len(desired.Args) > 0 && strings.Join(desired.Args, " ") != strings.Join(installed.Args, " ") {
+		add(fieldArgs, strings.Join(installed.Args, " "), strings.Join(desired.Args, " "))
+	}
+
+	if len(desired.Recovery) > 0 {
+		if recoveryString(installed) != recoveryString(desired) {
+			add(fieldRecovery, recoveryString(installed), recoveryString(desired))
+		}
+	}
+	return d
+}
+
+// accountOrDefault returns account of the service, empty is LocalSystem.
+func accountOrDefault(account string) string {
+	if account == "" {
+		return "LocalSystem"
+	}
+	return account
+}
+
+// recoveryString returns recovery of the configuration in compact form.
+func recoveryString(c Config) string {
+	reset := c.RecoveryReset
+	if reset == 0 {
+		reset = defaultRecoveryReset
+	}
+
+	parts := make([]string, 0, len(c.Recovery)+2)
+	for _, a := range c.Recovery {
+		parts = append(parts, fmt.Sprintf("%d/%ds", a.Type, a.Delay))
+	}
+	parts = append(parts, fmt.Sprintf("reset %ds", reset))
+	if c.RecoveryCommand != "" {
+		parts = append(parts, "command "+c.RecoveryCommand)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,38 @@
+package winsvc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffConfig(t *testing.T) {
+	installed := Config{
+		Name:        "gowinsvc",
+		DisplayName: "Go service",
+		Description: "old",
+		StartType:   StartAutomatic,
+		Account:     "LocalSystem",
+		Executable:  `C:\app\app.exe`,
+		Args:        []string{"-v"},
+	}
+
+	desired := Config{
+		Name:        "gowinsvc",
+		Description: "new",
+		Executable:  `c:\APP\app.exe`,
+		Args:        []string{"-v"},
+		Recovery:    []RecoveryAction{{Type: RecoveryRestart, Delay: 5}},
+	}
+
+	exp := Drift{
+		{Field: fieldDescription, Installed: "old", Desired: "new"},
+		{Field: fieldRecovery, Installed: "reset 86400s", Desired: "1/5s, reset 86400s"},
+	}
+	if got := diffConfig(installed, desired); !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if got := diffConfig(installed, installed); len(got) != 0 {
+		t.Errorf("exp: no drift, got: %v", got)
+	}
+}
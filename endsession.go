@@ -0,0 +1,161 @@
+// +build windows
+
+package winsvc
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Messages of the hidden window.
+const (
+	wmDestroy         = 0x0002
+	wmClose           = 0x0010
+	wmQueryEndSession = 0x0011
+	wmEndSession      = 0x0016
+
+	endSessionLogoff = 0x80000000 // ENDSESSION_LOGOFF of lParam of WM_ENDSESSION
+)
+
+var (
+	moduser32            = windows.NewLazySystemDLL("user32.dll")
+	procRegisterClassExW = moduser32.NewProc("RegisterClassExW")
+	procUnregisterClassW = moduser32.NewProc("UnregisterClassW")
+	procCreateWindowExW  = moduser32.NewProc("CreateWindowExW")
+	procDestroyWindow    = moduser32.NewProc("DestroyWindow")
+	procDefWindowProcW   = moduser32.NewProc("DefWindowProcW")
+	procGetMessageW      = moduser32.NewProc("GetMessageW")
+	procDispatchMessageW = moduser32.NewProc("DispatchMessageW")
+	procPostMessageW     = moduser32.NewProc("PostMessageW")
+	procPostQuitMessage  = moduser32.NewProc("PostQuitMessage")
+)
+
+// wndClassEx is WNDCLASSEXW structure.
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   windows.Handle
+	icon       windows.Handle
+	cursor     windows.Handle
+	background windows.Handle
+	menuName   *uint16
+	className  *uint16
+	iconSm     windows.Handle
+}
+
+// msg is MSG structure.
+type msg struct {
+	hwnd    windows.Handle
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// WithEndSessionWindow is a option to create hidden window in interactive mode which stops the service gracefully
+// on WM_ENDSESSION of logoff or shutdown (unless IgnoreShutdown is set). Programs of GUI subsystem (tray applications)
+// have no console, so they do not get console signals of logoff and shutdown.
+func WithEndSessionWindow() option {
+	return func(m *manager) {
+		m.endSessionWindow = true
+	}
+}
+
+// handleEndSession creates hidden window whose messages are dispatched by the locked OS thread.
+// It returns function which closes the window.
+func (m *manager) handleEndSession() (func(), error) {
+	created := make(chan error, 1)
+	hwnd := make(chan windows.Handle, 1)
+
+	go func() {
+		// messages of the window are received only by the thread which has created it
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		h, unregister, err := m.createEndSessionWindow()
+		created <- err
+		if err != nil {
+			return
+		}
+		defer unregister()
+		hwnd <- h
+
+		var msg msg
+		for {
+			if r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0); int32(r) <= 0 {
+				return
+			}
+			procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+		}
+	}()
+
+	if err := <-created; err != nil {
+		return nil, err
+	}
+
+	h := <-hwnd
+	// the window is not waited, because it waits stop of the service while session is ending
+	return func() { procPostMessageW.Call(uintptr(h), wmClose, 0, 0) }, nil
+}
+
+// createEndSessionWindow registers class and creates hidden top-level window,
+// message-only windows do not receive broadcast WM_ENDSESSION. It returns function which unregisters the class.
+func (m *manager) createEndSessionWindow() (windows.Handle, func(), error) {
+	var instance windows.Handle
+	if err := windows.GetModuleHandleEx(0, nil, &instance); err != nil {
+		return 0, nil, err
+	}
+
+	class := windows.StringToUTF16Ptr("winsvc-endsession")
+	wc := wndClassEx{
+		wndProc:   syscall.NewCallback(m.endSessionProc),
+		instance:  instance,
+		className: class,
+	}
+	wc.size = uint32(unsafe.Sizeof(wc))
+	if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+		return 0, nil, err
+	}
+	unregister := func() { procUnregisterClassW.Call(uintptr(unsafe.Pointer(class)), uintptr(instance)) }
+
+	h, _, err := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(class)), 0, 0, 0, 0, 0, 0, 0, 0, uintptr(instance), 0)
+	if h == 0 {
+		unregister()
+		return 0, nil, err
+	}
+	return windows.Handle(h), unregister, nil
+}
+
+// endSessionProc is a window procedure which stops the service when session ends.
+// Windows terminates the process when it returns, so it waits until the service is stopped.
+func (m *manager) endSessionProc(hwnd windows.Handle, message uint32, wParam, lParam uintptr) uintptr {
+	switch message {
+	case wmQueryEndSession:
+		return 1
+	case wmEndSession:
+		if wParam == 0 {
+			return 0 // ending of session is canceled
+		}
+
+		if lParam&endSessionLogoff == 0 && m.ignoreShutdown {
+			return 0
+		}
+
+		m.stopOnce.Do(func() { close(m.stopReq) })
+		<-m.done
+		return 0
+	case wmDestroy:
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+
+	r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(message), wParam, lParam)
+	return r
+}
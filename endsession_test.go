@@ -0,0 +1,40 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManager_EndSessionProc(t *testing.T) {
+	m := newManager(func(ctx context.Context) {}, IgnoreShutdown())
+	if got := m.endSessionProc(0, wmQueryEndSession, 0, 0); got != 1 {
+		t.Errorf("exp: 1, got: %d", got)
+	}
+
+	// shutdown is ignored, ending of session is canceled
+	m.endSessionProc(0, wmEndSession, 1, 0)
+	m.endSessionProc(0, wmEndSession, 0, endSessionLogoff)
+	select {
+	case <-m.stopReq:
+		t.Fatal("exp: service is not stopped")
+	default:
+	}
+
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		m.endSessionProc(0, wmEndSession, 1, endSessionLogoff)
+	}()
+
+	select {
+	case <-m.stopReq:
+	case <-time.After(time.Second):
+		t.Fatal("exp: stop of the service on logoff")
+	}
+
+	close(m.done)
+	<-returned
+}
@@ -0,0 +1,58 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// serviceEntry is a service of the service manager.
+type serviceEntry struct {
+	Name        string
+	DisplayName string
+	State       State
+}
+
+// enumServices returns win32 services of the service manager with display names and states.
+func enumServices(m *mgr.Mgr) ([]serviceEntry, error) {
+	var (
+		buf                       []byte
+		bytesNeeded, servicesRead uint32
+	)
+	for {
+		var p *byte
+		if len(buf) > 0 {
+			p = &buf[0]
+		}
+
+		err := windows.EnumServicesStatusEx(m.Handle, windows.SC_ENUM_PROCESS_INFO, windows.SERVICE_WIN32,
+			windows.SERVICE_STATE_ALL, p, uint32(len(buf)), &bytesNeeded, &servicesRead, nil, nil)
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, windows.ERROR_MORE_DATA) || bytesNeeded <= uint32(len(buf)) {
+			return nil, err
+		}
+		buf = make([]byte, bytesNeeded)
+	}
+
+	if servicesRead == 0 {
+		return nil, nil
+	}
+
+	services := (*[1 << 20]windows.ENUM_SERVICE_STATUS_PROCESS)(unsafe.Pointer(&buf[0]))[:servicesRead:servicesRead]
+	entries := make([]serviceEntry, 0, len(services))
+	for _, s := range services {
+		entries = append(entries, serviceEntry{
+			Name:        windows.UTF16PtrToString(s.ServiceName),
+			DisplayName: windows.UTF16PtrToString(s.DisplayName),
+			State:       State(s.ServiceStatusProcess.CurrentState),
+		})
+	}
+	return entries, nil
+}
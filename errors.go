@@ -0,0 +1,118 @@
+package winsvc
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotInstalled is returned when the service does not exist.
+	ErrNotInstalled = errors.New("service is not installed")
+	// ErrAlreadyExists is returned when the service with the same name or display name exists.
+	ErrAlreadyExists = errors.New("service already exists")
+	// ErrAccessDenied is returned when the process has not enough rights, usually it is not elevated.
+	ErrAccessDenied = errors.New("access denied")
+	// ErrTimeout is returned when the service has not reached the state in time.
+	ErrTimeout = errors.New("timeout")
+	// ErrRunExited is a value of panic when run function returns before the stop of service.
+	ErrRunExited = errors.New("exit from run function")
+	// ErrDatabaseLocked is returned when the service control manager database is locked.
+	ErrDatabaseLocked = errors.New("service database is locked")
+	// ErrMarkedForDeletion is returned when the service has been marked for deletion
+	// but it is not deleted yet, because somebody keeps handle of the service.
+	ErrMarkedForDeletion = errors.New("service is marked for deletion")
+	// ErrStartFailed is returned when the service has stopped while starting.
+	ErrStartFailed = errors.New("service has stopped while starting")
+	// ErrInvalidName is returned when the name of service is not accepted by the service manager or it is reserved.
+	ErrInvalidName = errors.New("invalid service name")
+	// ErrInvalidConfig is returned when the configuration is not valid (see Config.Validate).
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrClosed is returned when counters are updated after Close.
+	ErrClosed = errors.New("closed")
+)
+
+// ExitError is returned by RunE when exit code is set by Handle.SetExitCode in interactive mode.
+type ExitError struct {
+	Code uint32
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.Code)
+}
+
+// Exit codes of actions of the command line, they are stable, so scripts branch on outcomes without parsing of text.
+const (
+	ExitOK                = 0
+	ExitFailure           = 1  // other errors
+	ExitNotInstalled      = 2  // ErrNotInstalled
+	ExitTimeout           = 3  // ErrTimeout
+	ExitAccessDenied      = 4  // ErrAccessDenied
+	ExitAlreadyExists     = 5  // ErrAlreadyExists
+	ExitInvalidConfig     = 6  // ErrInvalidConfig or ErrInvalidName
+	ExitDatabaseLocked    = 7  // ErrDatabaseLocked
+	ExitMarkedForDeletion = 8  // ErrMarkedForDeletion
+	ExitStartFailed       = 9  // ErrStartFailed
+	ExitUsage             = 10 // unknown flags or invalid arguments of the action
+)
+
+// errUsage is an error of flags of the action.
+var errUsage = errors.New("invalid arguments")
+
+// exitCodes are exit codes of errors.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{ErrNotInstalled, ExitNotInstalled},
+	{ErrTimeout, ExitTimeout},
+	{ErrAccessDenied, ExitAccessDenied},
+	{ErrAlreadyExists, ExitAlreadyExists},
+	{ErrInvalidConfig, ExitInvalidConfig},
+	{ErrInvalidName, ExitInvalidConfig},
+	{ErrDatabaseLocked, ExitDatabaseLocked},
+	{ErrMarkedForDeletion, ExitMarkedForDeletion},
+	{ErrStartFailed, ExitStartFailed},
+	{errUsage, ExitUsage},
+}
+
+// ExitCode returns exit code of the process by error of RunE: 0 is nil, code of *ExitError,
+// code of the scheme of actions (ExitNotInstalled and etc) and ExitFailure is other errors.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var e *ExitError
+	if errors.As(err, &e) {
+		return int(e.Code)
+	}
+
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ExitFailure
+}
+
+// Error is an error of operation with the service.
+// It wraps the underlying error and suggests how to fix it.
+type Error struct {
+	Op     string // operation: install, uninstall, start and etc
+	Name   string // name of the service
+	Err    error  // underlying error
+	Remedy string // suggested remediation, can be empty
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("%s service %s: %v", e.Op, e.Name, e.Err)
+	if e.Remedy != "" {
+		s += " (" + e.Remedy + ")"
+	}
+	return s
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,93 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestWrapError(t *testing.T) {
+	err := wrapError("install", "test", windows.ERROR_SERVICE_MARKED_FOR_DELETE)
+	if !errors.Is(err, ErrMarkedForDeletion) {
+		t.Errorf("exp: %v, got: %v", ErrMarkedForDeletion, err)
+	}
+
+	if errors.Is(err, ErrDatabaseLocked) {
+		t.Errorf("exp: not %v", ErrDatabaseLocked)
+	}
+
+	if !errors.Is(err, windows.ERROR_SERVICE_MARKED_FOR_DELETE) {
+		t.Errorf("exp: windows error is unwrapped")
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("exp: *Error, got: %T", err)
+	}
+
+	if e.Op != "install" || e.Name != "test" || e.Remedy == "" {
+		t.Errorf("exp: filled error, got: %+v", e)
+	}
+}
+
+func TestWrapError_Nil(t *testing.T) {
+	if err := wrapError("install", "test", nil); err != nil {
+		t.Errorf("exp: nil, got: %v", err)
+	}
+}
+
+func TestWrapError_Filled(t *testing.T) {
+	err := wrapError("uninstall", "test", &Error{Err: windows.ERROR_SERVICE_DATABASE_LOCKED})
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Errorf("exp: %v, got: %v", ErrDatabaseLocked, err)
+	}
+
+	exp := "uninstall service test: " + windows.ERROR_SERVICE_DATABASE_LOCKED.Error()
+	if got := err.Error(); got != exp {
+		t.Errorf("exp: %s, got: %s", exp, got)
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+	}{
+		{windows.ERROR_SERVICE_DOES_NOT_EXIST, ErrNotInstalled},
+		{windows.ERROR_SERVICE_EXISTS, ErrAlreadyExists},
+		{windows.ERROR_DUPLICATE_SERVICE_NAME, ErrAlreadyExists},
+		{windows.ERROR_ACCESS_DENIED, ErrAccessDenied},
+		{fmt.Errorf("%w: service has not been running", ErrTimeout), ErrTimeout},
+	}
+
+	for _, tt := range tests {
+		err := wrapError("start", "test", tt.err)
+		if !errors.Is(err, tt.target) {
+			t.Errorf("exp: %v, got: %v", tt.target, err)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err error
+		exp int
+	}{
+		{nil, 0},
+		{ErrRunExited, 1},
+		{fmt.Errorf("run: %w", &ExitError{Code: 5}), 5},
+		{fmt.Errorf("stop: %w", ErrNotInstalled), ExitNotInstalled},
+		{ConfigErrors{errors.New("negative hook timeout")}, ExitInvalidConfig},
+		{fmt.Errorf("%w: flag provided but not defined", errUsage), ExitUsage},
+	}
+
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.exp {
+			t.Errorf("%v exp: %d, got: %d", tt.err, tt.exp, got)
+		}
+	}
+}
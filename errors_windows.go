@@ -0,0 +1,67 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// Is reports whether the underlying windows error matches target sentinel error.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotInstalled:
+		return errors.Is(e.Err, windows.ERROR_SERVICE_DOES_NOT_EXIST)
+	case ErrAlreadyExists:
+		return errors.Is(e.Err, windows.ERROR_SERVICE_EXISTS) || errors.Is(e.Err, windows.ERROR_DUPLICATE_SERVICE_NAME)
+	case ErrAccessDenied:
+		return errors.Is(e.Err, windows.ERROR_ACCESS_DENIED)
+	case ErrTimeout:
+		return errors.Is(e.Err, windows.ERROR_SERVICE_REQUEST_TIMEOUT)
+	case ErrDatabaseLocked:
+		return errors.Is(e.Err, windows.ERROR_SERVICE_DATABASE_LOCKED)
+	case ErrMarkedForDeletion:
+		return errors.Is(e.Err, windows.ERROR_SERVICE_MARKED_FOR_DELETE)
+	}
+	return false
+}
+
+// wrapError wraps err of operation op and adds remediation for known windows errors.
+func wrapError(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Op == "" {
+			e.Op = op
+		}
+		if e.Name == "" {
+			e.Name = name
+		}
+		return err
+	}
+
+	e = &Error{Op: op, Name: name, Err: err}
+	switch {
+	case errors.Is(e, ErrNotInstalled):
+		e.Remedy = "install the service or check its name"
+	case errors.Is(e, ErrAlreadyExists):
+		e.Remedy = "uninstall the existing service or choose another name"
+	case errors.Is(e, ErrAccessDenied):
+		e.Remedy = "run the command as administrator"
+	case errors.Is(e, ErrTimeout):
+		e.Remedy = "check event log of the service, it can be still pending"
+	case errors.Is(err, ErrStartFailed):
+		e.Remedy = "check event log of the service"
+	case errors.Is(err, ErrInvalidName):
+		e.Remedy = "use letters, digits, spaces, '-', '_' and '.' in the name"
+	case errors.Is(err, windows.ERROR_SERVICE_DATABASE_LOCKED):
+		e.Remedy = "wait until the other installation finishes and try again"
+	case errors.Is(err, windows.ERROR_SERVICE_MARKED_FOR_DELETE):
+		e.Remedy = "close services.msc, Event Viewer and other programs which open the service or reboot the machine"
+	}
+	return e
+}
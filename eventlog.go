@@ -0,0 +1,228 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Level is a level of event log entry.
+type Level uint16
+
+// Levels of event log entries.
+const (
+	LevelInfo    = Level(windows.EVENTLOG_INFORMATION_TYPE)
+	LevelWarning = Level(windows.EVENTLOG_WARNING_TYPE)
+	LevelError   = Level(windows.EVENTLOG_ERROR_TYPE)
+)
+
+// DefaultEventMessageFile is EventLogMessages.dll of .NET Framework 4, it contains message "%1" for every event identifier
+// from 0 to 65535, so entries are formatted without "description not found" text. The package does not ship own
+// message file, .NET Framework 4 must be installed (it is a part of Windows since 8 and Server 2012)
+// or Config.EventMessageFile must be set.
+const DefaultEventMessageFile = `%SystemRoot%\Microsoft.NET\Framework\v4.0.30319\EventLogMessages.dll`
+
+const (
+	eventLogKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application`
+	// eventRange is a count of identifiers of every level.
+	eventRange = 10000
+	// maxEventCode is the last code of the level, greater codes are clamped to it.
+	maxEventCode = eventRange - 1
+)
+
+// Codes of events which are written by the package.
+const (
+	codeStarted         = 1
+	codeStopped         = 2
+	codeRunExited       = 3
+	codeReloaded        = 4
+	codeReloadFailed    = 5
+	codeWatchFailed     = 6
+	codeStatusFailed    = 7
+	codeRestarted       = 8
+	codeMemoryLimit     = 9
+	codeCPULimit        = 10
+	codeHeartbeatFailed = 11
+	codeCrashLoop       = 12
+	codeHookCommand     = 13
+	codeHookFailed      = 14
+	codeKillTimeout     = 15
+	codeInvalidTimeout  = 16
+	codeRunPanic        = 17
+	codeAudit           = 18
+	codeNetworkTimeout  = 19
+	codeServiceFailed   = 20
+	codeProcessFailed   = 21
+	codeControlsFailed  = 22
+)
+
+// EventID returns stable identifier of event by its level and code (0-9999), greater codes are clamped to 9999,
+// so they do not collide with identifiers of other levels. Identifiers are grouped by level:
+//
+//	information 10000-19999
+//	warning     20000-29999
+//	error       30000-39999
+//
+// For example EventID(LevelError, 1001) is 31001.
+func EventID(level Level, code uint16) uint32 {
+	var base uint32
+	switch level {
+	case LevelInfo:
+		base = 1
+	case LevelWarning:
+		base = 2
+	case LevelError:
+		base = 3
+	}
+	return base*eventRange + clampCode(code)
+}
+
+// clampCode returns code in range of identifiers of the level.
+func clampCode(code uint16) uint32 {
+	if code > maxEventCode {
+		return maxEventCode
+	}
+	return uint32(code)
+}
+
+// severity returns order of level, error is the highest.
+func severity(l Level) int {
+	switch l {
+	case LevelInfo:
+		return 1
+	case LevelWarning:
+		return 2
+	case LevelError:
+		return 3
+	}
+	return 0
+}
+
+// EventMap maps levels of entries to stable identifiers of events and categories, so rules of SIEM which are keyed
+// by identifiers keep working when codes are added. Zero value is the scheme of EventID.
+//
+//	winsvc.WithEventMap(winsvc.EventMap{
+//		Base:       map[winsvc.Level]uint32{winsvc.LevelError: 5000},
+//		Categories: map[winsvc.Level]uint16{winsvc.LevelError: 2},
+//	})
+type EventMap struct {
+	Base       map[Level]uint32 // base identifier of level, identifier of entry is base + code (0-9999)
+	Categories map[Level]uint16 // category of entries of level which are written without category
+}
+
+// id returns identifier of entry by its level and code.
+func (e EventMap) id(level Level, code uint16) uint32 {
+	if base, ok := e.Base[level]; ok {
+		return base + clampCode(code)
+	}
+	return EventID(level, code)
+}
+
+// category returns category of entry, category 0 is replaced by category of level.
+func (e EventMap) category(level Level, category uint16) uint16 {
+	if category == 0 {
+		return e.Categories[level]
+	}
+	return category
+}
+
+// WithEventMap is a option to set identifiers of events and categories of entries which are written by the service.
+func WithEventMap(e EventMap) option {
+	return func(m *manager) {
+		m.eventMap = e
+	}
+}
+
+// EventLog writes entries of the service to Application event log.
+type EventLog struct {
+	log     *eventlog.Log
+	mapping EventMap
+}
+
+// OpenEventLog opens event log of the source.
+func OpenEventLog(source string) (*EventLog, error) {
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &EventLog{log: l}, nil
+}
+
+// SetEventMap sets identifiers of events and categories of entries.
+func (l *EventLog) SetEventMap(e EventMap) {
+	l.mapping = e
+}
+
+// Close closes event log.
+func (l *EventLog) Close() error {
+	return l.log.Close()
+}
+
+// Report writes entry with the category, category 0 is none.
+// Categories are shown if message file of the source has them (see Config.EventCategoryCount).
+// Identifier and category are mapped by EventMap (see SetEventMap).
+func (l *EventLog) Report(level Level, category uint16, code uint16, msg string) error {
+	ss := []*uint16{syscall.StringToUTF16Ptr(msg)}
+	return windows.ReportEvent(l.log.Handle, uint16(level), l.mapping.category(level, category), l.mapping.id(level, code), 0, 1, 0, &ss[0], nil)
+}
+
+// Info writes information entry.
+func (l *EventLog) Info(code uint16, msg string) error {
+	return l.Report(LevelInfo, 0, code, msg)
+}
+
+// Warning writes warning entry.
+func (l *EventLog) Warning(code uint16, msg string) error {
+	return l.Report(LevelWarning, 0, code, msg)
+}
+
+// Error writes error entry.
+func (l *EventLog) Error(code uint16, msg string) error {
+	return l.Report(LevelError, 0, code, msg)
+}
+
+// installEventSource registers source of event log with message file.
+func installEventSource(source, msgFile string, categories uint32) error {
+	if msgFile == "" {
+		msgFile = DefaultEventMessageFile
+	}
+
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, eventLogKey+`\`+source, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	if err := k.SetExpandStringValue("EventMessageFile", msgFile); err != nil {
+		return err
+	}
+
+	if categories > 0 {
+		if err := k.SetExpandStringValue("CategoryMessageFile", msgFile); err != nil {
+			return err
+		}
+
+		if err := k.SetDWordValue("CategoryCount", categories); err != nil {
+			return err
+		}
+	}
+	return k.SetDWordValue("TypesSupported", eventlog.Info|eventlog.Warning|eventlog.Error)
+}
+
+// removeEventSource removes source of event log, not existed source is skipped.
+func removeEventSource(source string) error {
+	err := registry.DeleteKey(registry.LOCAL_MACHINE, eventLogKey+`\`+source)
+	if errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("remove event source: %w", err)
+	}
+	return nil
+}
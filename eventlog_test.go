@@ -0,0 +1,49 @@
+// +build windows
+
+package winsvc
+
+import "testing"
+
+func TestEventID(t *testing.T) {
+	tests := []struct {
+		level Level
+		code  uint16
+		exp   uint32
+	}{
+		{LevelInfo, 1, 10001},
+		{LevelWarning, 42, 20042},
+		{LevelError, 1001, 31001},
+		{LevelError, 9999, 39999},
+		{LevelError, 10001, 39999},
+		{LevelInfo, 65535, 19999},
+	}
+
+	for _, tt := range tests {
+		if got := EventID(tt.level, tt.code); got != tt.exp {
+			t.Errorf("exp: %d, got: %d", tt.exp, got)
+		}
+	}
+}
+
+func TestEventMap(t *testing.T) {
+	e := EventMap{
+		Base:       map[Level]uint32{LevelError: 5000},
+		Categories: map[Level]uint16{LevelError: 2},
+	}
+
+	if got := e.id(LevelError, 12); got != 5012 {
+		t.Errorf("exp: 5012, got: %d", got)
+	}
+	if got := e.id(LevelInfo, 12); got != 10012 {
+		t.Errorf("exp: 10012, got: %d", got)
+	}
+	if got := e.category(LevelError, 0); got != 2 {
+		t.Errorf("exp: 2, got: %d", got)
+	}
+	if got := e.category(LevelError, 5); got != 5 {
+		t.Errorf("exp: 5, got: %d", got)
+	}
+	if got := e.category(LevelInfo, 0); got != 0 {
+		t.Errorf("exp: 0, got: %d", got)
+	}
+}
@@ -0,0 +1,339 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	evtQueryChannelPath       = 0x1
+	evtQueryReverseDirection  = 0x200
+	evtSubscribeStartAtOldest = 0x2
+	evtRenderEventXML         = 0x1
+	evtNextBatch              = 16
+	evtWatchInterval          = time.Millisecond * 500
+	eventLogChannel           = "Application"
+	eventTimeFormat           = "2006-01-02T15:04:05.000Z"
+)
+
+var (
+	modwevtapi       = windows.NewLazySystemDLL("wevtapi.dll")
+	procEvtQuery     = modwevtapi.NewProc("EvtQuery")
+	procEvtSubscribe = modwevtapi.NewProc("EvtSubscribe")
+	procEvtNext      = modwevtapi.NewProc("EvtNext")
+	procEvtRender    = modwevtapi.NewProc("EvtRender")
+	procEvtClose     = modwevtapi.NewProc("EvtClose")
+)
+
+// Event is an entry of event log of the service.
+type Event struct {
+	Record   uint64 // number of record in the log
+	Time     time.Time
+	Level    Level
+	ID       uint32 // identifier of event, see EventID
+	Category uint16
+	Message  string // inserted strings of entry separated by new line
+}
+
+// String returns entry in one line form.
+func (e Event) String() string {
+	return fmt.Sprintf("%s %-7s %5d %s", e.Time.Local().Format("2006-01-02 15:04:05"), levelString(e.Level), e.ID,
+		strings.Replace(e.Message, "\n", " ", -1))
+}
+
+// ReadOption is an option of reading of event log.
+type ReadOption func(*eventQuery)
+
+// eventQuery is a filter of entries of event log.
+type eventQuery struct {
+	since time.Time
+	level Level
+	limit int
+}
+
+// ReadSince is an option to read entries which are written since t.
+func ReadSince(t time.Time) ReadOption {
+	return func(q *eventQuery) {
+		q.since = t
+	}
+}
+
+// ReadLevel is an option to read entries with level not lower than l, for example LevelWarning reads warnings and errors.
+func ReadLevel(l Level) ReadOption {
+	return func(q *eventQuery) {
+		q.level = l
+	}
+}
+
+// ReadLimit is an option to read only n the latest entries.
+func ReadLimit(n int) ReadOption {
+	return func(q *eventQuery) {
+		q.limit = n
+	}
+}
+
+// xpath returns query of entries of the source in Application event log.
+func (q eventQuery) xpath(source string) string {
+	cond := []string{fmt.Sprintf("Provider[@Name='%s']", source)}
+	switch q.level {
+	case LevelWarning:
+		cond = append(cond, "(Level=1 or Level=2 or Level=3)")
+	case LevelError:
+		cond = append(cond, "(Level=1 or Level=2)")
+	}
+
+	if !q.since.IsZero() {
+		cond = append(cond, fmt.Sprintf("TimeCreated[@SystemTime>='%s']", q.since.UTC().Format(eventTimeFormat)))
+	}
+	return fmt.Sprintf("*[System[%s]]", strings.Join(cond, " and "))
+}
+
+// ReadEvents returns entries of the source (name of the service) from Application event log in order of writing.
+func ReadEvents(source string, opts ...ReadOption) ([]Event, error) {
+	var q eventQuery
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	flags := uintptr(evtQueryChannelPath)
+	if q.limit > 0 {
+		flags |= evtQueryReverseDirection
+	}
+
+	h, _, err := procEvtQuery.Call(0, uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(eventLogChannel))),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(q.xpath(source)))), flags)
+	if h == 0 {
+		return nil, fmt.Errorf("query event log: %w", err)
+	}
+	defer procEvtClose.Call(h)
+
+	var events []Event
+	for q.limit <= 0 || len(events) < q.limit {
+		batch, err := nextEvents(h)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(batch) == 0 {
+			break
+		}
+		events = append(events, batch...)
+	}
+
+	if q.limit > 0 {
+		if len(events) > q.limit {
+			events = events[:q.limit]
+		}
+
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	return events, nil
+}
+
+// WatchEvents calls f for entries of the source from Application event log and then for new entries
+// until context is canceled or f returns error. ReadSince(time.Now()) skips the written entries, ReadLimit is ignored.
+func WatchEvents(ctx context.Context, source string, f func(e Event) error, opts ...ReadOption) error {
+	var q eventQuery
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	signal, err := windows.CreateEvent(nil, 1, 1, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(signal)
+
+	h, _, err := procEvtSubscribe.Call(0, uintptr(signal), uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(eventLogChannel))),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(q.xpath(source)))), 0, 0, 0, evtSubscribeStartAtOldest)
+	if h == 0 {
+		return fmt.Errorf("subscribe event log: %w", err)
+	}
+	defer procEvtClose.Call(h)
+
+	for {
+		ev, err := windows.WaitForSingleObject(signal, uint32(evtWatchInterval/time.Millisecond))
+		if err != nil {
+			return err
+		}
+
+		if ev == windows.WAIT_OBJECT_0 {
+			for {
+				batch, err := nextEvents(h)
+				if err != nil {
+					return err
+				}
+
+				if len(batch) == 0 {
+					break
+				}
+
+				for _, e := range batch {
+					if err := f(e); err != nil {
+						return err
+					}
+				}
+			}
+			windows.ResetEvent(signal)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+}
+
+// nextEvents returns the next batch of entries of query or subscription, it is empty if there are no more entries.
+func nextEvents(h uintptr) ([]Event, error) {
+	var (
+		handles  [evtNextBatch]uintptr
+		returned uint32
+	)
+	r, _, err := procEvtNext.Call(h, evtNextBatch, uintptr(unsafe.Pointer(&handles[0])), 0, 0, uintptr(unsafe.Pointer(&returned)))
+	if r == 0 {
+		if errors.Is(err, windows.ERROR_NO_MORE_ITEMS) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read event log: %w", err)
+	}
+
+	events := make([]Event, 0, returned)
+	for _, eh := range handles[:returned] {
+		data, err := renderEvent(eh)
+		procEvtClose.Call(eh)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := parseEvent(data)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// renderEvent returns entry of event log as XML.
+func renderEvent(h uintptr) (string, error) {
+	buf := make([]uint16, 1024)
+	for {
+		var used, count uint32
+		r, _, err := procEvtRender.Call(0, h, evtRenderEventXML, uintptr(len(buf)*2), uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&used)), uintptr(unsafe.Pointer(&count)))
+		if r != 0 {
+			return windows.UTF16ToString(buf), nil
+		}
+
+		if !errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) {
+			return "", fmt.Errorf("render event: %w", err)
+		}
+		buf = make([]uint16, used/2+1)
+	}
+}
+
+// eventXML is an entry of event log in XML form.
+type eventXML struct {
+	System struct {
+		EventID     uint32 `xml:"EventID"`
+		Level       uint8  `xml:"Level"`
+		Task        uint16 `xml:"Task"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID uint64 `xml:"EventRecordID"`
+	} `xml:"System"`
+	Data []string `xml:"EventData>Data"`
+}
+
+// parseEvent returns entry of event log by its XML form.
+func parseEvent(data string) (Event, error) {
+	var x eventXML
+	if err := xml.Unmarshal([]byte(data), &x); err != nil {
+		return Event{}, fmt.Errorf("parse event: %w", err)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, x.System.TimeCreated.SystemTime)
+	if err != nil {
+		return Event{}, fmt.Errorf("parse event: %w", err)
+	}
+
+	level := LevelInfo
+	switch x.System.Level {
+	case 1, 2: // critical and error
+		level = LevelError
+	case 3:
+		level = LevelWarning
+	}
+
+	return Event{
+		Record:   x.System.EventRecordID,
+		Time:     t,
+		Level:    level,
+		ID:       x.System.EventID,
+		Category: x.System.Task,
+		Message:  strings.Join(x.Data, "\n"),
+	}, nil
+}
+
+// levelString returns human readable level.
+func levelString(l Level) string {
+	switch l {
+	case LevelWarning:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	}
+	return "INFO"
+}
+
+// parseLevel returns level by its name.
+func parseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "", "info", "information":
+		return LevelInfo, nil
+	case "warning", "warn":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown level %q", s)
+}
+
+// printLogs prints entries of the service since time with level not lower than min,
+// new entries are printed until the process is stopped if follow is true.
+func printLogs(w io.Writer, source string, since time.Time, min Level, follow bool) error {
+	opts := []ReadOption{ReadSince(since), ReadLevel(min)}
+	if follow {
+		return WatchEvents(context.Background(), source, func(e Event) error {
+			_, err := fmt.Fprintln(w, e)
+			return err
+		}, opts...)
+	}
+
+	events, err := ReadEvents(source, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if _, err := fmt.Fprintln(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
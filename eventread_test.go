@@ -0,0 +1,37 @@
+// +build windows
+
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventQuery_XPath(t *testing.T) {
+	q := eventQuery{since: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC), level: LevelWarning}
+	exp := "*[System[Provider[@Name='gowinsvc'] and (Level=1 or Level=2 or Level=3) and TimeCreated[@SystemTime>='2021-03-10T12:00:00.000Z']]]"
+	if got := q.xpath("gowinsvc"); got != exp {
+		t.Errorf("exp: %s, got: %s", exp, got)
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	data := `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='gowinsvc'/>` +
+		`<EventID Qualifiers='0'>20005</EventID><Level>3</Level><Task>2</Task><TimeCreated SystemTime='2021-03-10T12:00:00.5Z'/>` +
+		`<EventRecordID>7</EventRecordID><Channel>Application</Channel></System><EventData><Data>stop</Data><Data>timeout</Data></EventData></Event>`
+
+	e, err := parseEvent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := Event{Record: 7, Time: time.Date(2021, 3, 10, 12, 0, 0, 5e8, time.UTC), Level: LevelWarning, ID: 20005, Category: 2, Message: "stop\ntimeout"}
+	if !e.Time.Equal(exp.Time) {
+		t.Errorf("exp: %v, got: %v", exp.Time, e.Time)
+	}
+
+	e.Time = exp.Time
+	if e != exp {
+		t.Errorf("exp: %+v, got: %+v", exp, e)
+	}
+}
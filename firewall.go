@@ -0,0 +1,75 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// firewallRulesValue is a value of registry key of the service with names of created firewall rules.
+const firewallRulesValue = "WinsvcFirewallRules"
+
+// WithFirewallRule is a option to create inbound rule of Windows Firewall at install,
+// the rule allows connections to the port of protocol (tcp or udp) only for the service.
+// The rule is removed on uninstall.
+func WithFirewallRule(name string, port uint16, protocol string) option {
+	return func(m *manager) {
+		m.config.FirewallRules = append(m.config.FirewallRules, FirewallRule{Name: name, Port: port, Protocol: protocol})
+	}
+}
+
+// addFirewallRules creates firewall rules of the service, the rules are scoped by SID of the service.
+func addFirewallRules(name, exe string, rules []FirewallRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var added []string
+	for _, r := range rules {
+		protocol := strings.ToUpper(r.Protocol)
+		if protocol == "" {
+			protocol = "TCP"
+		}
+
+		err := netsh("add", "rule", "name="+r.Name, "dir=in", "action=allow", "enable=yes",
+			"protocol="+protocol, "localport="+strconv.Itoa(int(r.Port)), "program="+exe, "service="+name)
+		if err != nil {
+			deleteFirewallRules(added)
+			return fmt.Errorf("firewall rule %s: %w", r.Name, err)
+		}
+		added = append(added, r.Name)
+	}
+
+	if err := trackArtifacts(name, firewallRulesValue, added); err != nil {
+		deleteFirewallRules(added)
+		return err
+	}
+	return nil
+}
+
+// removeFirewallRules deletes firewall rules which have been created at install.
+func removeFirewallRules(name string) error {
+	return deleteFirewallRules(trackedArtifacts(name, firewallRulesValue))
+}
+
+// deleteFirewallRules deletes firewall rules by names.
+func deleteFirewallRules(names []string) error {
+	for _, n := range names {
+		if err := netsh("delete", "rule", "name="+n); err != nil {
+			return fmt.Errorf("firewall rule %s: %w", n, err)
+		}
+	}
+	return nil
+}
+
+// netsh runs command of netsh advfirewall firewall.
+func netsh(args ...string) error {
+	out, err := exec.Command("netsh", append([]string{"advfirewall", "firewall"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
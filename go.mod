@@ -2,4 +2,4 @@ module github.com/itcomusic/winsvc
 
 go 1.13
 
-require golang.org/x/sys v0.0.0-20190916202348-b4ddaad3f8a3
+require golang.org/x/sys v0.7.0
@@ -0,0 +1,20 @@
+module github.com/itcomusic/winsvc/grpcctl
+
+go 1.17
+
+require (
+	github.com/Microsoft/go-winio v0.5.2
+	github.com/itcomusic/winsvc v0.0.0-00010101000000-000000000000
+	golang.org/x/sys v0.13.0
+	google.golang.org/grpc v1.56.3
+	google.golang.org/protobuf v1.30.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+)
+
+replace github.com/itcomusic/winsvc => ../
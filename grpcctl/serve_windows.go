@@ -0,0 +1,69 @@
+// +build windows
+
+package grpcctl
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/itcomusic/winsvc"
+	"golang.org/x/sys/windows/registry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// pipeSDDL allows the pipe only to SYSTEM and administrators.
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)"
+
+// PipeName returns name of the named pipe of the endpoint of the service.
+func PipeName(name string) string {
+	return `\\.\pipe\` + name + "-control"
+}
+
+// New returns endpoint of the service name, Status reports state of h and extended status of the service (see winsvc.ReadStatus).
+func New(name string, h *winsvc.Handle, opts ...option) *Server {
+	s := newServer(append([]option{withStatus(func() (*StatusResponse, error) { return readStatus(name, h) })}, opts...)...)
+	s.name = name
+	return s
+}
+
+// Serve serves the endpoint on the named pipe of the service until ctx is done, calls in progress are finished then.
+func (s *Server) Serve(ctx context.Context) error {
+	l, err := winio.ListenPipe(PipeName(s.name), &winio.PipeConfig{SecurityDescriptor: pipeSDDL})
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, l)
+}
+
+// Dial connects to the endpoint of the service name, connection must be closed.
+//
+//	conn, err := grpcctl.Dial(ctx, "gowinsvc")
+//	resp, err := grpcctl.NewControlClient(conn).Status(ctx, &grpcctl.StatusRequest{})
+func Dial(ctx context.Context, name string) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, PipeName(name),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(winio.DialPipeContext))
+}
+
+// readStatus returns state of h with extended status of the service if it is published.
+func readStatus(name string, h *winsvc.Handle) (*StatusResponse, error) {
+	resp := &StatusResponse{State: h.State().String(), Pid: uint32(os.Getpid())}
+	st, err := winsvc.ReadStatus(name)
+	if errors.Is(err, registry.ErrNotExist) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Version = st.Version
+	resp.Health = st.Health
+	resp.Fields = st.Fields
+	if !st.StartTime.IsZero() {
+		resp.StartTime = st.StartTime.Unix()
+	}
+	return resp, nil
+}
@@ -0,0 +1,173 @@
+// Package grpcctl provides gRPC control endpoint of the running service (see control.proto),
+// it manages the service beyond controls of the service manager: status, reload, drain and dump of stacks.
+// The endpoint is served on the named pipe of the service which is accessible by SYSTEM and administrators
+// or on the loopback address.
+//
+// It is a separate module, so programs of package winsvc do not depend on gRPC and keep Go 1.13,
+// gRPC requires Go 1.17. Directive replace of go.mod builds the module with winsvc of the repository.
+package grpcctl
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type option func(*Server)
+
+// WithReload is a option to reload configuration of the service by Reload, for example reload of winsvc.WatchConfig.
+func WithReload(reload func(ctx context.Context) error) option {
+	return func(s *Server) {
+		s.reload = reload
+	}
+}
+
+// WithDrain is a option to finish work in progress of the service by Drain.
+func WithDrain(drain func(ctx context.Context) error) option {
+	return func(s *Server) {
+		s.drain = drain
+	}
+}
+
+// withStatus is a option to return status of the service by Status.
+func withStatus(f func() (*StatusResponse, error)) option {
+	return func(s *Server) {
+		s.status = f
+	}
+}
+
+// Server implements ControlServer, Reload and Drain return codes.Unimplemented if their options are not set.
+type Server struct {
+	UnimplementedControlServer
+	name   string
+	status func() (*StatusResponse, error)
+	reload func(ctx context.Context) error
+	drain  func(ctx context.Context) error
+}
+
+func newServer(opts ...option) *Server {
+	s := &Server{}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// ServeLocal serves the endpoint on the loopback address (for example "127.0.0.1:7070") until ctx is done,
+// calls in progress are finished then. Unlike the named pipe any local process can call the endpoint.
+func (s *Server) ServeLocal(ctx context.Context, addr string) error {
+	if err := checkLoopback(addr); err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, l)
+}
+
+// DialLocal connects to the endpoint which is served on the loopback address, connection must be closed.
+func DialLocal(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	if err := checkLoopback(addr); err != nil {
+		return nil, err
+	}
+	return grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// checkLoopback returns error if host of the address is not loopback.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("address %s is not loopback", addr)
+	}
+	return nil
+}
+
+// serve serves the endpoint on l until ctx is done or serving fails, calls in progress are finished then.
+func (s *Server) serve(ctx context.Context, l net.Listener) error {
+	gs := grpc.NewServer()
+	RegisterControlServer(gs, s)
+
+	served := make(chan struct{})
+	defer close(served)
+	go func() {
+		select {
+		case <-ctx.Done():
+			gs.GracefulStop()
+		case <-served:
+		}
+	}()
+
+	if err := gs.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Status returns state and extended status of the service.
+func (s *Server) Status(ctx context.Context, _ *StatusRequest) (*StatusResponse, error) {
+	if s.status == nil {
+		return nil, status.Error(codes.Unimplemented, "status is not set")
+	}
+
+	resp, err := s.status()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "status: %v", err)
+	}
+	return resp, nil
+}
+
+// Reload reloads configuration of the service.
+func (s *Server) Reload(ctx context.Context, _ *ReloadRequest) (*ReloadResponse, error) {
+	if s.reload == nil {
+		return nil, status.Error(codes.Unimplemented, "reload is not set")
+	}
+
+	if err := s.reload(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "reload: %v", err)
+	}
+	return &ReloadResponse{}, nil
+}
+
+// Drain finishes work in progress of the service.
+func (s *Server) Drain(ctx context.Context, _ *DrainRequest) (*DrainResponse, error) {
+	if s.drain == nil {
+		return nil, status.Error(codes.Unimplemented, "drain is not set")
+	}
+
+	if err := s.drain(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "drain: %v", err)
+	}
+	return &DrainResponse{}, nil
+}
+
+// DumpStacks returns stacks of all goroutines of the service.
+func (s *Server) DumpStacks(context.Context, *DumpStacksRequest) (*DumpStacksResponse, error) {
+	return &DumpStacksResponse{Stacks: stacks()}, nil
+}
+
+// stacks returns stacks of all goroutines, buffer grows until the stacks fit it.
+func stacks() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
@@ -0,0 +1,119 @@
+package grpcctl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testClient returns client of the server which is served in memory.
+func testClient(t *testing.T, s *Server) ControlClient {
+	t.Helper()
+	l := bufconn.Listen(1 << 16)
+	gs := grpc.NewServer()
+	RegisterControlServer(gs, s)
+	go gs.Serve(l)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewControlClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	var reloaded bool
+	c := testClient(t, newServer(
+		withStatus(func() (*StatusResponse, error) {
+			return &StatusResponse{State: "running", Fields: map[string]string{"queue": "3"}}, nil
+		}),
+		WithReload(func(ctx context.Context) error {
+			reloaded = true
+			return nil
+		}),
+		WithDrain(func(ctx context.Context) error { return errors.New("busy") }),
+	))
+	ctx := context.Background()
+
+	st, err := c.Status(ctx, &StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.State != "running" || st.Fields["queue"] != "3" {
+		t.Errorf("exp: running with queue 3, got: %v", st)
+	}
+
+	if _, err := c.Reload(ctx, &ReloadRequest{}); err != nil || !reloaded {
+		t.Errorf("exp: reload, got: %t %v", reloaded, err)
+	}
+
+	if _, err := c.Drain(ctx, &DrainRequest{}); status.Code(err) != codes.Internal {
+		t.Errorf("exp: %s, got: %v", codes.Internal, err)
+	}
+
+	stacks, err := c.DumpStacks(ctx, &DumpStacksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stacks.Stacks, "goroutine ") {
+		t.Errorf("exp: stacks of goroutines, got: %q", stacks.Stacks)
+	}
+}
+
+func TestServer_Unimplemented(t *testing.T) {
+	c := testClient(t, newServer())
+	ctx := context.Background()
+
+	if _, err := c.Reload(ctx, &ReloadRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("exp: %s, got: %v", codes.Unimplemented, err)
+	}
+	if _, err := c.Drain(ctx, &DrainRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("exp: %s, got: %v", codes.Unimplemented, err)
+	}
+}
+
+// failedListener fails to accept connections.
+type failedListener struct{ net.Listener }
+
+func (failedListener) Accept() (net.Conn, error) { return nil, errors.New("accept failed") }
+
+func TestServer_Serve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := bufconn.Listen(1 << 16)
+	served := make(chan error, 1)
+	go func() { served <- newServer().serve(ctx, l) }()
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("exp: %v, got: %v", nil, err)
+	}
+
+	if err := newServer().serve(context.Background(), failedListener{bufconn.Listen(1)}); err == nil {
+		t.Errorf("exp: error of accept")
+	}
+}
+
+func TestCheckLoopback(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:7070", "[::1]:7070", "localhost:7070"} {
+		if err := checkLoopback(addr); err != nil {
+			t.Errorf("exp: loopback %s, got: %v", addr, err)
+		}
+	}
+
+	for _, addr := range []string{"0.0.0.0:7070", ":7070", "example.com:7070", "127.0.0.1"} {
+		if err := checkLoopback(addr); err == nil {
+			t.Errorf("exp: error of %s", addr)
+		}
+	}
+}
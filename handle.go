@@ -0,0 +1,89 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Handle controls the service from the program.
+//
+//	h := winsvc.New(run)
+//	h.OnStop(func() { log.Print("[INFO] stopping") })
+//	go h.Run()
+//	<-h.Done()
+type Handle struct {
+	m *manager
+}
+
+// New initializes new windows service without running it.
+// Unlike Run, several services can be created, but only one of them can be run by OS service manager.
+func New(r runFunc, opts ...option) *Handle {
+	return &Handle{m: newManager(r, opts...)}
+}
+
+// Run runs the service or command action, it is blocked like winsvc.Run.
+// Run must be called once.
+func (h *Handle) Run() {
+	h.m.run()
+}
+
+// RunE runs the service like Run, but it returns error instead of panic (see winsvc.RunE).
+// RunE must be called once instead of Run.
+func (h *Handle) RunE() error {
+	h.m.returnErr = true
+	h.m.run()
+	return h.m.err
+}
+
+// State returns the current state of the service.
+func (h *Handle) State() State {
+	return State(atomic.LoadUint32(&h.m.state))
+}
+
+// StopAsync requests the stop of service and returns immediately.
+// Done is closed when the service is stopped.
+func (h *Handle) StopAsync() {
+	h.m.stopOnce.Do(func() { close(h.m.stopReq) })
+}
+
+// SetExitCode sets exit code of the service. In service mode it is service-specific exit code which is reported
+// to the service manager at stop, not zero code is a failure for recovery actions of the service manager.
+// In interactive mode RunE returns *ExitError with the code (see ExitCode), Run does not use it.
+func (h *Handle) SetExitCode(code uint32) {
+	atomic.StoreUint32(&h.m.exitCode, code)
+}
+
+// Done returns channel which is closed when Run returns.
+func (h *Handle) Done() <-chan struct{} {
+	return h.m.done
+}
+
+// OnStart registers hook which is called after the service is running.
+// Hooks must be registered before Run.
+func (h *Handle) OnStart(f func()) {
+	h.m.onStart = append(h.m.onStart, f)
+}
+
+// OnStop registers hook which is called before context of run function is canceled.
+// Hooks must be registered before Run.
+func (h *Handle) OnStop(f func()) {
+	h.m.onStop = append(h.m.onStop, f)
+}
+
+// OnInterrogate registers hook which is called when the service manager interrogates the service,
+// for example to refresh extended status (see SetHealth). The current status is reported after hooks.
+// Hooks must be registered before Run.
+func (h *Handle) OnInterrogate(f func()) {
+	h.m.onInterrogate = append(h.m.onInterrogate, f)
+}
+
+// OnDrain registers hook which finishes work in progress (for example in-flight requests) before context of run function is canceled.
+// Context of hook is done after timeout of stop. Progress is reported by report, it is passed to the service manager
+// as checkpoints of stop pending state, so the service is not considered hung. Wait hint of checkpoint is expected time
+// of the remaining total-done items by average time of done items.
+// Hooks must be registered before Run.
+func (h *Handle) OnDrain(f func(ctx context.Context, report func(done, total int))) {
+	h.m.onDrain = append(h.m.onDrain, f)
+}
@@ -0,0 +1,163 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestHandle_StopAsync(t *testing.T) {
+	h := New(func(ctx context.Context) {
+		<-ctx.Done()
+	}, signalNotify(func(c chan<- os.Signal, sig ...os.Signal) {}))
+
+	started := make(chan struct{})
+	stopped := false
+	h.OnStart(func() { close(started) })
+	h.OnStop(func() { stopped = true })
+	go h.Run()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second * 5):
+		t.Fatal("service has not been started")
+	}
+
+	if got := h.State(); got != Running {
+		t.Errorf("exp: %s, got: %s", Running, got)
+	}
+
+	h.StopAsync()
+	h.StopAsync()
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second * 5):
+		t.Fatal("service has not been stopped")
+	}
+
+	if got := h.State(); got != Stopped {
+		t.Errorf("exp: %s, got: %s", Stopped, got)
+	}
+
+	if !stopped {
+		t.Errorf("exp: stop hook is called")
+	}
+}
+
+func TestHandle_OnDrain(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	h.OnDrain(func(ctx context.Context, report func(done, total int)) {
+		for i := 1; i <= 3; i++ {
+			report(i, 3)
+		}
+	})
+
+	got := controlScript(t, h.m, svc.Stop)
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending, svc.StopPending, svc.StopPending, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestHandle_OnNetBind(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	var got []NetBindChange
+	h.OnNetBind(func(c NetBindChange) { got = append(got, c) })
+
+	controlScript(t, h.m, svc.NetBindAdd, svc.NetBindDisable, svc.Stop)
+	exp := []NetBindChange{NetBindAdd, NetBindDisable}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestHandle_OnTriggerEvent(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	var called int
+	h.OnTriggerEvent(func(e TriggerEvent) { called++ })
+
+	if h.m.acceptedControls()&acceptTriggerEvent == 0 {
+		t.Errorf("exp: accepted trigger event")
+	}
+
+	// status with extended accepted controls is passed by the same way as other statuses
+	got := controlScript(t, h.m, cmdTriggerEvent, cmdTriggerEvent, svc.Stop)
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+	if called != 2 {
+		t.Errorf("exp: %d, got: %d", 2, called)
+	}
+}
+
+func TestHandle_OnTimeChange(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	var got [2]time.Time
+	h.OnTimeChange(func(old, new time.Time) { got = [2]time.Time{old, new} })
+
+	before, after := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC), time.Date(2021, 3, 10, 11, 0, 0, 0, time.UTC)
+	info := serviceTimeChangeInfo{newTime: windows.NsecToFiletime(after.UnixNano()), oldTime: windows.NsecToFiletime(before.UnixNano())}
+	h.m.timeChange(copyEventData(cmdTimeChange, uintptr(unsafe.Pointer(&info))))
+	if !got[0].Equal(before) || !got[1].Equal(after) {
+		t.Errorf("exp: %v %v, got: %v", before, after, got)
+	}
+}
+
+func TestHandle_OnInterrogate(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	var called int
+	h.OnInterrogate(func() { called++ })
+
+	got := controlScript(t, h.m, svc.Interrogate, svc.Interrogate, svc.Stop)
+	exp := []svc.State{svc.StartPending, svc.Running, svc.Running, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if called != 2 {
+		t.Errorf("exp: %d, got: %d", 2, called)
+	}
+}
+
+func TestHandle_SetExitCode(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	h.OnStart(func() { h.SetExitCode(42) })
+	h.m.ctxSvc, h.m.cancelSvc = newRunContext()
+
+	r := make(chan svc.ChangeRequest, 1)
+	changes := make(chan svc.Status, 10)
+	r <- svc.ChangeRequest{Cmd: svc.Stop}
+
+	ssec, code := h.m.Execute(nil, r, changes)
+	if !ssec || code != 42 {
+		t.Errorf("exp: service-specific %d, got: %t %d", 42, ssec, code)
+	}
+}
+
+func TestHandle_OnLogoff(t *testing.T) {
+	h := New(func(ctx context.Context) { <-ctx.Done() })
+	var got []uint32
+	h.OnLogoff(func(id uint32) { got = append(got, id) })
+
+	if h.m.acceptedControls()&svc.AcceptSessionChange == 0 {
+		t.Errorf("exp: accepted session change")
+	}
+
+	n := windows.WTSSESSION_NOTIFICATION{SessionID: 3}
+	n.Size = uint32(unsafe.Sizeof(n))
+	d := copyEventData(svc.SessionChange, uintptr(unsafe.Pointer(&n)))
+	h.m.sessionChange(SessionLock, d)
+	h.m.sessionChange(SessionLogoff, d)
+	if !reflect.DeepEqual(got, []uint32{3}) {
+		t.Errorf("exp: [3], got: %v", got)
+	}
+}
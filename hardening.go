@@ -0,0 +1,48 @@
+package winsvc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HardeningProfile is a set of security restrictions of the service which are applied at install.
+type HardeningProfile struct {
+	// RestrictedSID makes token of the service write-restricted: the service writes only to objects
+	// which allow SID of the service (NT SERVICE\<name>), Everyone or write-restricted SID.
+	RestrictedSID bool `json:"restricted_sid,omitempty"`
+	// Privileges are the only privileges which are kept in token of the service, others are removed.
+	// Nil keeps all privileges of the account.
+	Privileges []string `json:"privileges,omitempty"`
+	// TightDACL allows to control the service only to administrators and SYSTEM,
+	// other users can only query configuration and status.
+	TightDACL bool `json:"tight_dacl,omitempty"`
+}
+
+// Presets of hardening.
+var (
+	// HardeningBaseline keeps privileges which are usually needed by network services and restricts control of the service.
+	HardeningBaseline = HardeningProfile{
+		Privileges: []string{"SeChangeNotifyPrivilege", "SeCreateGlobalPrivilege", "SeImpersonatePrivilege"},
+		TightDACL:  true,
+	}
+	// HardeningStrict is HardeningBaseline with write-restricted token and only the privilege of traversal.
+	HardeningStrict = HardeningProfile{
+		RestrictedSID: true,
+		Privileges:    []string{"SeChangeNotifyPrivilege"},
+		TightDACL:     true,
+	}
+)
+
+// tightDACL allows full control to SYSTEM and administrators, authenticated users query configuration,
+// status and dependents and interrogate the service.
+const tightDACL = "D:(A;;CCLCSWRPWPDTLOCRRC;;;SY)(A;;CCDCLCSWRPWPDTLOCRSDRCWDWO;;;BA)(A;;CCLCSWLORC;;;AU)"
+
+// validate returns error if name of privilege is not like SeNamePrivilege.
+func (h HardeningProfile) validate() error {
+	for _, p := range h.Privileges {
+		if len(p) <= len("SePrivilege") || !strings.HasPrefix(p, "Se") || !strings.HasSuffix(p, "Privilege") {
+			return fmt.Errorf("hardening: invalid privilege %q", p)
+		}
+	}
+	return nil
+}
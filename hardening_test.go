@@ -0,0 +1,18 @@
+package winsvc
+
+import "testing"
+
+func TestHardeningProfile_Validate(t *testing.T) {
+	for _, h := range []HardeningProfile{{}, HardeningBaseline, HardeningStrict} {
+		if err := h.validate(); err != nil {
+			t.Errorf("exp: nil, got: %v", err)
+		}
+	}
+
+	for _, p := range []string{"", "Privilege", "SePrivilege", "ChangeNotify", "SeChangeNotify"} {
+		h := HardeningProfile{Privileges: []string{p}}
+		if err := h.validate(); err == nil {
+			t.Errorf("exp: error of %q", p)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// WithHardening is a option to apply security restrictions to the service at install:
+// write-restricted token, stripped privileges and DACL of the service.
+//
+//	winsvc.Run(run, winsvc.WithHardening(winsvc.HardeningStrict))
+func WithHardening(h HardeningProfile) option {
+	return func(m *manager) {
+		m.config.Hardening = &h
+	}
+}
+
+// sidType returns type of SID of the service, firewall rules and URL reservations are scoped by SID of the service.
+func sidType(c Config) uint32 {
+	switch {
+	case c.Hardening != nil && c.Hardening.RestrictedSID:
+		return windows.SERVICE_SID_TYPE_RESTRICTED
+	case len(c.FirewallRules) > 0 || len(c.URLReservations) > 0:
+		return windows.SERVICE_SID_TYPE_UNRESTRICTED
+	}
+	return windows.SERVICE_SID_TYPE_NONE
+}
+
+// harden applies privileges and DACL of the profile to the service.
+func harden(h windows.Handle, p *HardeningProfile) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.Privileges != nil {
+		if err := setRequiredPrivileges(h, p.Privileges); err != nil {
+			return err
+		}
+	}
+
+	if !p.TightDACL {
+		return nil
+	}
+
+	sd, err := windows.SecurityDescriptorFromString(tightDACL)
+	if err != nil {
+		return err
+	}
+
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	return windows.SetSecurityInfo(h, windows.SE_SERVICE, windows.DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+}
+
+// setRequiredPrivileges sets the only privileges which are kept in token of the service.
+func setRequiredPrivileges(h windows.Handle, privileges []string) error {
+	// SeChangeNotifyPrivilege is always kept by the service manager, it makes empty list valid
+	list := append([]string{"SeChangeNotifyPrivilege"}, privileges...)
+	ms, err := windows.UTF16FromString(strings.Join(list, "\x00") + "\x00")
+	if err != nil {
+		return err
+	}
+
+	info := struct{ privileges *uint16 }{&ms[0]}
+	return windows.ChangeServiceConfig2(h, windows.SERVICE_CONFIG_REQUIRED_PRIVILEGES_INFO, (*byte)(unsafe.Pointer(&info)))
+}
+
+// serviceACEs returns allowing entries of SDDL with flags of inheritance and rights for SID of the service
+// and the account, LocalSystem has no own entry.
+func serviceACEs(name, account, flags, rights string) (string, error) {
+	accounts := []string{`NT SERVICE\` + name}
+	if !isLocalSystem(account) {
+		accounts = append(accounts, strings.TrimPrefix(account, `.\`))
+	}
+
+	var aces string
+	for _, a := range accounts {
+		sid, _, _, err := windows.LookupSID("", a)
+		if err != nil {
+			return "", fmt.Errorf("account %s: %w", a, err)
+		}
+		aces += fmt.Sprintf("(A;%s;%s;;;%s)", flags, rights, sid)
+	}
+	return aces, nil
+}
+
+// setDACL sets protected DACL of SDDL to the named object, it is not inherited from the parent.
+func setDACL(path string, typ windows.SE_OBJECT_TYPE, sddl string) error {
+	sd, err := windows.SecurityDescriptorFromString(sddl)
+	if err != nil {
+		return err
+	}
+
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(path, typ,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+}
@@ -0,0 +1,71 @@
+// +build windows
+
+package winsvc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// WithHeartbeat is a option to write heartbeat (time and state of the service) every interval,
+// so external watchdogs detect the service which is wedged but running.
+// Heartbeat is written to the file if path is set, otherwise to the extended status in registry (see ReadStatus).
+// It is written by the loop which handles controls of the service manager.
+func WithHeartbeat(interval time.Duration, path string) option {
+	return func(m *manager) {
+		m.heartbeat = interval
+		m.heartbeatFile = path
+	}
+}
+
+// startHeartbeat returns channel of ticks of heartbeat and function which stops it, channel is nil if heartbeat is not set.
+func (m *manager) startHeartbeat() (<-chan time.Time, func()) {
+	if m.heartbeat <= 0 {
+		return nil, func() {}
+	}
+
+	t := time.NewTicker(m.heartbeat)
+	m.writeHeartbeat(time.Now())
+	return t.C, t.Stop
+}
+
+// writeHeartbeat writes heartbeat, the failure is reported once until heartbeat is written again.
+func (m *manager) writeHeartbeat(now time.Time) {
+	ts := now.UTC().Format(time.RFC3339)
+	state := State(atomic.LoadUint32(&m.state)).String()
+
+	var err error
+	if m.heartbeatFile != "" {
+		err = writeFileAtomic(m.heartbeatFile, []byte(ts+" "+state+"\n"))
+	} else if err = m.status.set(statusHeartbeat, ts); err == nil {
+		err = m.status.set(statusState, state)
+	}
+
+	if err != nil && !m.heartbeatFailed {
+		m.report(LevelWarning, codeHeartbeatFailed, "write heartbeat: "+err.Error())
+	}
+	m.heartbeatFailed = err != nil
+}
+
+// writeFileAtomic writes data to temporary file and renames it, so readers do not see partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,36 @@
+// +build windows
+
+package winsvc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestWriteHeartbeat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "winsvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "heartbeat")
+	m := newManager(nil, WithHeartbeat(time.Second, path))
+	m.setState(svc.Running)
+	m.writeHeartbeat(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "2021-03-10T12:00:00Z running\n"
+	if got := string(b); got != exp {
+		t.Errorf("exp: %q, got: %q", exp, got)
+	}
+}
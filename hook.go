@@ -0,0 +1,55 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultHookTimeout is a timeout of hook commands if it is not set.
+	defaultHookTimeout = time.Second * 30
+	// maxHookOutput is a size of output of hook command which is written to event log.
+	maxHookOutput = 4096
+)
+
+// addHookCommands registers hook commands of the configuration, they are run after hooks which are registered by the program.
+func (m *manager) addHookCommands(c Config) {
+	timeout := defaultHookTimeout
+	if c.HookTimeout > 0 {
+		timeout = time.Duration(c.HookTimeout) * time.Second
+	}
+
+	if len(c.PostStart) > 0 {
+		m.onStart = append(m.onStart, func() { m.runHookCommand("post-start", c.PostStart, timeout) })
+	}
+
+	if len(c.PreStop) > 0 {
+		m.onStop = append(m.onStop, func() { m.runHookCommand("pre-stop", c.PreStop, timeout) })
+	}
+}
+
+// runHookCommand runs command and writes its output to event log.
+func (m *manager) runHookCommand(name string, command []string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	msg := name + " " + strings.Join(command, " ")
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	if len(out) > maxHookOutput {
+		out = append(out[:maxHookOutput], "..."...)
+	}
+
+	if err != nil {
+		m.report(LevelWarning, codeHookFailed, msg+": "+err.Error()+"\n"+string(out))
+		return
+	}
+	m.report(LevelInfo, codeHookCommand, msg+"\n"+string(out))
+}
@@ -0,0 +1,23 @@
+// +build windows
+
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManager_AddHookCommands(t *testing.T) {
+	m := newManager(nil)
+	m.addHookCommands(Config{PostStart: []string{"cmd", "/c", "exit 0"}, PreStop: []string{"ping", "-n", "30", "127.0.0.1"}, HookTimeout: 1})
+
+	if len(m.onStart) != 1 || len(m.onStop) != 1 {
+		t.Fatalf("exp: hooks, got: %d, %d", len(m.onStart), len(m.onStop))
+	}
+
+	begin := time.Now()
+	m.callHooks(m.onStop)
+	if d := time.Since(begin); d > time.Second*5 {
+		t.Errorf("exp: command is stopped by timeout, got: %s", d)
+	}
+}
@@ -0,0 +1,19 @@
+package winsvc
+
+import "strings"
+
+// pathQuoted reports whether executable of command line of the service is quoted or has no spaces.
+// Windows tries every prefix of unquoted path with spaces ("C:\Program.exe" for "C:\Program Files\app.exe"),
+// so it is a well-known vector of privilege escalation.
+func pathQuoted(cmdline string) bool {
+	cmdline = strings.TrimSpace(cmdline)
+	if strings.HasPrefix(cmdline, `"`) {
+		return true
+	}
+
+	exe := cmdline
+	if i := strings.Index(strings.ToLower(cmdline), ".exe"); i >= 0 {
+		exe = cmdline[:i]
+	}
+	return !strings.ContainsAny(exe, " \t")
+}
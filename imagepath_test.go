@@ -0,0 +1,22 @@
+package winsvc
+
+import "testing"
+
+func TestPathQuoted(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		exp     bool
+	}{
+		{`C:\app\app.exe`, true},
+		{`C:\app\app.exe -config C:\Program Files\app.json`, true},
+		{`"C:\Program Files\app\app.exe" -v`, true},
+		{`C:\Program Files\app\app.exe`, false},
+		{`C:\Program Files\app\app.EXE -v`, false},
+	}
+
+	for _, tt := range tests {
+		if got := pathQuoted(tt.cmdline); got != tt.exp {
+			t.Errorf("%s exp: %t, got: %t", tt.cmdline, tt.exp, got)
+		}
+	}
+}
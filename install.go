@@ -0,0 +1,353 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Install creates the service.
+// It returns ErrDatabaseLocked if the database of the service manager is locked
+// and ErrMarkedForDeletion if the previous service has not been deleted yet.
+func Install(c Config) error {
+	return InstallContext(context.Background(), c)
+}
+
+// InstallContext is Install which is canceled when context is done, completed steps are undone then.
+func InstallContext(ctx context.Context, c Config) error {
+	return audit("install", c.Name, "", wrapError("install", c.Name, install(ctx, c)))
+}
+
+// Uninstall stops and deletes the service and artifacts which have been created at install:
+// event log source, firewall rules, URL reservations, performance counters, settings of crash dumps, parameters and directories of data.
+// It returns ErrDatabaseLocked if the database of the service manager is locked
+// and ErrMarkedForDeletion if the service has already been deleted.
+func Uninstall(name string) error {
+	return UninstallContext(context.Background(), name)
+}
+
+// UninstallContext is Uninstall which waits stop of the service until context is done, it waits 30s if context has no deadline.
+func UninstallContext(ctx context.Context, name string) error {
+	return uninstall(ctx, name, false)
+}
+
+// UninstallKeepData is Uninstall which keeps directories of data (Config.DataDirs).
+func UninstallKeepData(name string) error {
+	return uninstall(context.Background(), name, true)
+}
+
+func install(ctx context.Context, c Config) error {
+	if err := validateName(c.Name); err != nil {
+		return err
+	}
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c = localizeConfig(c, systemLocales())
+
+	exe, err := executablePath(c.Executable)
+	if err != nil {
+		return err
+	}
+
+	account, pw, err := accountPassword(c)
+	if err != nil {
+		return err
+	}
+	defer pw.wipe()
+	c.Account, c.Password = account, ""
+
+	startType := c.StartType
+	if startType == 0 {
+		startType = mgr.StartAutomatic
+	}
+
+	m, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	if err := checkLock(m); err != nil {
+		return err
+	}
+
+	if s, err := m.OpenService(c.Name); err == nil {
+		defer s.Close()
+		if err := checkDeletion(s); err != nil {
+			return err
+		}
+		return windows.ERROR_SERVICE_EXISTS
+	}
+
+	if err := checkDisplayName(m, c.DisplayName); err != nil {
+		return err
+	}
+
+	// SERVICE_INTERACTIVE_PROCESS is allowed only for LocalSystem and windows are shown in session 0,
+	// which is not seen by users since Windows Vista
+	var serviceType uint32
+	if c.Interactive {
+		if c.Account != "" && !strings.EqualFold(c.Account, "LocalSystem") {
+			return &Error{Err: windows.ERROR_INVALID_PARAMETER, Remedy: "interactive service must run as LocalSystem"}
+		}
+		serviceType = windows.SERVICE_WIN32_OWN_PROCESS | windows.SERVICE_INTERACTIVE_PROCESS
+	}
+
+	s, err := m.CreateService(c.Name, exe, mgr.Config{
+		ServiceType:      serviceType,
+		StartType:        startType,
+		DelayedAutoStart: c.DelayedAutoStart,
+		Dependencies:     c.Dependencies,
+		ServiceStartName: c.Account, // password is set from locked memory after create
+		DisplayName:      c.DisplayName,
+		Description:      c.Description,
+		SidType:          sidType(c),
+	}, c.Args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// every completed step is undone if the next step fails, so the service is not left half-configured
+	var undo rollback
+	undo.add(s.Delete)
+	steps := []struct {
+		do   func() error
+		undo func() error
+	}{
+		{func() error { return setServicePassword(s.Handle, pw) }, nil},
+		{func() error { return setRecovery(s, c) }, nil},
+		{func() error { return harden(s.Handle, c.Hardening) }, nil},
+		{func() error { return seedParameters(c.Name, c.Parameters) }, nil}, // it is deleted with the service
+		{func() error { return protectParameters(c.Name, c.Account) }, nil},
+		{func() error { return createStatusKey(c.Name, c.Account) }, nil}, // it is deleted with the service
+		{func() error { return writeTags(c.Name, c.Tags) }, nil},          // it is deleted with the service
+		{func() error { return installEventSource(c.Name, c.EventMessageFile, c.EventCategoryCount) }, func() error { return removeEventSource(c.Name) }},
+		{func() error { return addFirewallRules(c.Name, exe, c.FirewallRules) }, func() error { return removeFirewallRules(c.Name) }},
+		{func() error { return addURLReservations(c) }, func() error { return removeURLReservations(c.Name) }},
+		{func() error { return registerCounters(c.Name, exe, c.Counters) }, func() error { return unregisterCounters(c.Name) }},
+		{func() error { return createDataDirs(c.Name, c.DataDirs) }, func() error { return removeDataDirs(c.Name) }},
+		{func() error { return createProgramData(c) }, nil}, // created directories are removed by undo of data directories
+		{func() error { return registerCrashDumps(c, exe) }, func() error { return unregisterCrashDumps(c.Name) }},
+	}
+
+	for _, step := range steps {
+		// undo is added before the step, because failed step can be partially completed
+		if step.undo != nil {
+			undo.add(step.undo)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return undo.run(err)
+		}
+
+		if err := step.do(); err != nil {
+			return undo.run(err)
+		}
+	}
+	return nil
+}
+
+// rollback is a stack of undo functions of completed steps of install.
+type rollback []func() error
+
+// add adds undo function of the step.
+func (r *rollback) add(f func() error) {
+	*r = append(*r, f)
+}
+
+// run calls undo functions in reverse order and returns err of the failed step,
+// errors of undo are attached to it, so the operator knows what has been left.
+func (r rollback) run(err error) error {
+	var failed []string
+	for i := len(r) - 1; i >= 0; i-- {
+		if e := r[i](); e != nil {
+			failed = append(failed, e.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w (rollback: %s)", err, strings.Join(failed, "; "))
+	}
+	return err
+}
+
+// executablePath returns absolute path of executable of the service without quotes, it is quoted by the service manager
+// if it has spaces. Path of the current executable is returned if exe is empty.
+func executablePath(exe string) (string, error) {
+	exe = strings.Trim(strings.TrimSpace(exe), `"`)
+	if exe == "" {
+		return os.Executable()
+	}
+	return filepath.Abs(exe)
+}
+
+// uninstall deletes the service and writes audit entry before source of event log is removed,
+// so the entry of uninstall is written by the source of the service.
+func uninstall(ctx context.Context, name string, keepData bool) error {
+	var details string
+	if keepData {
+		details = "data is kept"
+	}
+
+	deleted, err := deleteService(ctx, name, keepData)
+	err = audit("uninstall", name, details, wrapError("uninstall", name, err))
+	if !deleted {
+		return err
+	}
+
+	if errSource := removeEventSource(name); errSource != nil {
+		if err != nil {
+			return fmt.Errorf("%w; %v", err, errSource)
+		}
+		return wrapError("uninstall", name, fmt.Errorf("service is deleted, artifacts are left: %w", errSource))
+	}
+	return err
+}
+
+// deleteService stops and deletes the service and removes its artifacts except source of event log,
+// it reports whether the service is deleted.
+func deleteService(ctx context.Context, name string, keepData bool) (bool, error) {
+	m, err := connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer m.Disconnect()
+
+	if err := checkLock(m); err != nil {
+		return false, err
+	}
+
+	s, err := openService(ctx, m, name)
+	if err != nil {
+		return false, err
+	}
+	defer s.Close()
+
+	if err := checkDeletion(s); err != nil {
+		return false, err
+	}
+
+	if err := stopService(ctx, s, nil); err != nil {
+		return false, err
+	}
+
+	// artifacts are tracked by the key of the service, they are read before it is deleted with the service
+	rules := trackedArtifacts(name, firewallRulesValue)
+	urls := trackedArtifacts(name, urlReservationsValue)
+	manifests := trackedArtifacts(name, perfManifestValue)
+	dumps := trackedArtifacts(name, crashDumpsValue)
+	var dirs []string
+	if !keepData {
+		dirs = trackedArtifacts(name, dataDirsValue)
+	}
+
+	// artifacts are removed after the service is deleted, so failed deletion leaves the service installed as it was,
+	// key of parameters is deleted with the key of service
+	if err := s.Delete(); err != nil {
+		return false, err
+	}
+
+	var failed []string
+	for _, cleanup := range []func() error{
+		func() error { return deleteFirewallRules(rules) },
+		func() error { return deleteURLReservations(urls) },
+		func() error { return deleteCounters(manifests) },
+		func() error { return deleteCrashDumps(dumps) },
+		func() error { return deleteDataDirs(dirs) },
+	} {
+		if err := cleanup(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return true, fmt.Errorf("service is deleted, artifacts are left: %s", strings.Join(failed, "; "))
+	}
+	return true, nil
+}
+
+// checkLock returns error if the database of the service manager is locked.
+func checkLock(m *mgr.Mgr) error {
+	lock, err := m.LockStatus()
+	if err != nil {
+		return err
+	}
+
+	if lock.IsLocked {
+		return &Error{
+			Err:    windows.ERROR_SERVICE_DATABASE_LOCKED,
+			Remedy: fmt.Sprintf("locked by %q for %s, wait until it finishes and try again", lock.Owner, lock.Age),
+		}
+	}
+	return nil
+}
+
+// checkDisplayName returns error if other service has the same display name,
+// the service manager rejects it with the error which does not name the service.
+// Indirect strings of resources are not checked, they are resolved by language of the user.
+func checkDisplayName(m *mgr.Mgr, displayName string) error {
+	if displayName == "" || isMUIString(displayName) {
+		return nil
+	}
+
+	services, err := enumServices(m)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range services {
+		if strings.EqualFold(s.DisplayName, displayName) {
+			return &Error{
+				Err:    windows.ERROR_DUPLICATE_SERVICE_NAME,
+				Remedy: fmt.Sprintf("service %q has the same display name, choose another display name", s.Name),
+			}
+		}
+	}
+	return nil
+}
+
+// checkDeletion returns error if the service is marked for deletion.
+// Changing of config without changes fails only for such services.
+func checkDeletion(s *mgr.Service) error {
+	err := windows.ChangeServiceConfig(s.Handle, windows.SERVICE_NO_CHANGE, windows.SERVICE_NO_CHANGE,
+		windows.SERVICE_NO_CHANGE, nil, nil, nil, nil, nil, nil, nil)
+	if errors.Is(err, windows.ERROR_SERVICE_MARKED_FOR_DELETE) {
+		return err
+	}
+	return nil
+}
+
+// trackArtifacts saves names of artifacts which are created at install to the value of registry key of the service,
+// so they are removed on uninstall.
+func trackArtifacts(name, value string, items []string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, servicesKey+name, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringsValue(value, items)
+}
+
+// trackedArtifacts returns names of artifacts which are created at install.
+func trackedArtifacts(name, value string) []string {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, servicesKey+name, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer k.Close()
+
+	items, _, _ := k.GetStringsValue(value)
+	return items
+}
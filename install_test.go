@@ -0,0 +1,45 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRollback(t *testing.T) {
+	var (
+		undone  []int
+		errStep = errors.New("step")
+		r       rollback
+	)
+	r.add(func() error { undone = append(undone, 1); return nil })
+	r.add(func() error { undone = append(undone, 2); return errors.New("undo 2") })
+	r.add(func() error { undone = append(undone, 3); return nil })
+
+	err := r.run(errStep)
+	if !errors.Is(err, errStep) {
+		t.Errorf("exp: %v, got: %v", errStep, err)
+	}
+
+	exp := "step (rollback: undo 2)"
+	if err.Error() != exp {
+		t.Errorf("exp: %s, got: %s", exp, err)
+	}
+
+	if !reflect.DeepEqual(undone, []int{3, 2, 1}) {
+		t.Errorf("exp: reverse order, got: %v", undone)
+	}
+}
+
+func TestExecutablePath(t *testing.T) {
+	got, err := executablePath(`"C:\Program Files\app\app.exe"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := `C:\Program Files\app\app.exe`; got != exp {
+		t.Errorf("exp: %s, got: %s", exp, got)
+	}
+}
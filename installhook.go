@@ -0,0 +1,89 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// InstallHooks are called around install and uninstall actions of the command line,
+// for example to create data directories or remove files of the service.
+// The action fails if the hook fails, the service is uninstalled if post-install hook fails,
+// so install is a single operation from the operator's view.
+type InstallHooks struct {
+	PreInstall    func(c Config) error
+	PostInstall   func(c Config) error
+	PreUninstall  func(c Config) error
+	PostUninstall func(c Config) error
+}
+
+// WithInstallHooks is a option to specify hooks of install and uninstall actions.
+// Hook commands of Config are run after the hooks.
+func WithInstallHooks(h InstallHooks) option {
+	return func(m *manager) {
+		m.installHooks = h
+	}
+}
+
+// installWithHooks installs the service and calls hooks around it.
+func (m *manager) installWithHooks(c Config) error {
+	if err := callInstallHook(c, m.installHooks.PreInstall, c.PreInstall); err != nil {
+		return fmt.Errorf("pre-install: %w", err)
+	}
+
+	if err := Install(c); err != nil {
+		return err
+	}
+
+	if err := callInstallHook(c, m.installHooks.PostInstall, c.PostInstall); err != nil {
+		if uerr := Uninstall(c.Name); uerr != nil {
+			return fmt.Errorf("post-install: %w (rollback: %v)", err, uerr)
+		}
+		return fmt.Errorf("post-install: %w", err)
+	}
+	return nil
+}
+
+// uninstallWithHooks uninstalls the service and calls hooks around it.
+func (m *manager) uninstallWithHooks(c Config, keepData bool) error {
+	if err := callInstallHook(c, m.installHooks.PreUninstall, c.PreUninstall); err != nil {
+		return fmt.Errorf("pre-uninstall: %w", err)
+	}
+
+	uninstall := Uninstall
+	if keepData {
+		uninstall = UninstallKeepData
+	}
+
+	if err := uninstall(c.Name); err != nil {
+		return err
+	}
+
+	if err := callInstallHook(c, m.installHooks.PostUninstall, c.PostUninstall); err != nil {
+		return fmt.Errorf("post-uninstall: %w", err)
+	}
+	return nil
+}
+
+// callInstallHook calls hook of the program and then hook command, name of the service is passed to command by EnvName.
+func callInstallHook(c Config, hook func(c Config) error, command []string) error {
+	if hook != nil {
+		if err := hook(c); err != nil {
+			return err
+		}
+	}
+
+	if len(command) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), EnvName+"="+c.Name)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", command[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
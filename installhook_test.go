@@ -0,0 +1,41 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestManager_InstallWithHooks_PreInstallFailed(t *testing.T) {
+	errHook := errors.New("hook")
+	var called []string
+	m := newManager(nil, WithInstallHooks(InstallHooks{
+		PreInstall: func(c Config) error {
+			called = append(called, "pre "+c.Name)
+			return errHook
+		},
+		PostInstall: func(c Config) error {
+			called = append(called, "post "+c.Name)
+			return nil
+		},
+	}))
+
+	if err := m.installWithHooks(Config{Name: "winsvc-test"}); !errors.Is(err, errHook) {
+		t.Errorf("exp: %v, got: %v", errHook, err)
+	}
+
+	if len(called) != 1 || called[0] != "pre winsvc-test" {
+		t.Errorf("exp: only pre-install hook, got: %v", called)
+	}
+}
+
+func TestCallInstallHook_Command(t *testing.T) {
+	if err := callInstallHook(Config{Name: "test"}, nil, []string{"cmd", "/c", "if not %WINSVC_NAME%==test exit 1"}); err != nil {
+		t.Errorf("exp: nil, got: %v", err)
+	}
+
+	if err := callInstallHook(Config{}, nil, []string{"cmd", "/c", "exit 1"}); err == nil {
+		t.Errorf("exp: error")
+	}
+}
@@ -0,0 +1,57 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// controlKey is a registry key with settings of the service manager.
+const controlKey = `SYSTEM\CurrentControlSet\Control`
+
+// waitToKillServiceTimeout returns time which the system waits for services at shutdown before they are killed.
+func waitToKillServiceTimeout() (time.Duration, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, controlKey, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, err
+	}
+	defer k.Close()
+
+	v, _, err := k.GetStringValue("WaitToKillServiceTimeout")
+	if err != nil {
+		return 0, err
+	}
+
+	ms, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("WaitToKillServiceTimeout %q: %w", v, err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// checkKillTimeout reads budget of stop at shutdown of the system and warns when timeout of stop at shutdown exceeds it,
+// since the service is killed by the system after it. The timeout is clamped to the budget (see controlTimeout).
+func (m *manager) checkKillTimeout() {
+	d, err := waitToKillServiceTimeout()
+	if err != nil || d == 0 {
+		return
+	}
+	m.killTimeout = d
+
+	if t := m.shutdownTimeout(); t > d {
+		m.report(LevelWarning, codeKillTimeout, fmt.Sprintf("timeout of stop at shutdown %s exceeds WaitToKillServiceTimeout %s of the system, it is reduced", t, d))
+	}
+}
+
+// shutdownTimeout returns timeout of stop at shutdown of the system which is set by options.
+func (m *manager) shutdownTimeout() time.Duration {
+	if m.timeoutShutdown > 0 {
+		return m.timeoutShutdown
+	}
+	return m.timeout
+}
@@ -0,0 +1,134 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Instance is a service which runs the executable.
+type Instance struct {
+	Name        string
+	DisplayName string
+	State       State
+	Args        []string
+}
+
+// Instances returns services whose binary is the executable with any arguments,
+// so all instances of multi-instance deployment are found.
+func Instances(exe string) ([]Instance, error) {
+	exe, err := filepath.Abs(exe)
+	if err != nil {
+		return nil, err
+	}
+
+	return findServices(func(path string) bool {
+		return strings.EqualFold(filepath.Clean(path), exe)
+	})
+}
+
+// FindOrphans returns services whose executable is in the directory (or its subdirectories), but it does not exist,
+// for example old versions have been deleted at upgrade, but their services have been left.
+// Orphans are uninstalled if remove is true.
+func FindOrphans(dir string, remove bool) ([]Instance, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans, err := findServices(func(path string) bool {
+		if !inDir(path, dir) {
+			return false
+		}
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	})
+	if err != nil || !remove {
+		return orphans, err
+	}
+
+	for _, s := range orphans {
+		if err := Uninstall(s.Name); err != nil {
+			return orphans, err
+		}
+	}
+	return orphans, nil
+}
+
+// inDir reports whether path is in the directory or its subdirectories.
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(strings.ToLower(dir), strings.ToLower(filepath.Clean(path)))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, `..\`)
+}
+
+// findServices returns services whose executable matches.
+func findServices(match func(path string) bool) ([]Instance, error) {
+	m, err := connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer m.Disconnect()
+
+	services, err := enumServices(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Instance
+	for _, s := range services {
+		path, args, err := imagePath(s.Name)
+		if err != nil || !match(path) {
+			continue
+		}
+		list = append(list, Instance{Name: s.Name, DisplayName: s.DisplayName, State: s.State, Args: args})
+	}
+	return list, nil
+}
+
+// imagePath returns executable and arguments of the service from registry.
+func imagePath(name string) (string, []string, error) {
+	v, err := rawImagePath(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args, err := windows.DecomposeCommandLine(v)
+	if err != nil || len(args) == 0 {
+		return "", nil, fmt.Errorf("image path %q: %v", v, err)
+	}
+	return args[0], args[1:], nil
+}
+
+// rawImagePath returns command line of the service with expanded environment variables.
+func rawImagePath(name string) (string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, servicesKey+name, registry.QUERY_VALUE)
+	if err != nil {
+		return "", err
+	}
+	defer k.Close()
+
+	v, _, err := k.GetStringValue("ImagePath")
+	if err != nil {
+		return "", err
+	}
+	return registry.ExpandString(v)
+}
+
+// printInstances prints services in table.
+func printInstances(w io.Writer, list []Instance) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tDISPLAY NAME\tARGUMENTS")
+	for _, s := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.State, s.DisplayName, strings.Join(s.Args, " "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,45 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintInstances(t *testing.T) {
+	var b bytes.Buffer
+	err := printInstances(&b, []Instance{
+		{Name: "app-1", DisplayName: "App 1", State: Running, Args: []string{"-port", "80"}},
+		{Name: "app-2", DisplayName: "App 2", State: Stopped},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "NAME   STATE    DISPLAY NAME  ARGUMENTS\n" +
+		"app-1  running  App 1         -port 80\n" +
+		"app-2  stopped  App 2         \n"
+	if got := b.String(); got != exp {
+		t.Errorf("exp: %q, got: %q", exp, got)
+	}
+}
+
+func TestInDir(t *testing.T) {
+	tests := []struct {
+		path, dir string
+		exp       bool
+	}{
+		{`C:\app\v1\app.exe`, `C:\app`, true},
+		{`c:\APP\app.exe`, `C:\app`, true},
+		{`C:\app2\app.exe`, `C:\app`, false},
+		{`C:\other\app.exe`, `C:\app`, false},
+		{`D:\app\app.exe`, `C:\app`, false},
+	}
+
+	for _, tt := range tests {
+		if got := inDir(tt.path, tt.dir); got != tt.exp {
+			t.Errorf("%s in %s exp: %t, got: %t", tt.path, tt.dir, tt.exp, got)
+		}
+	}
+}
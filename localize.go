@@ -0,0 +1,43 @@
+package winsvc
+
+import "strings"
+
+// isMUIString reports whether s is indirect string of resource "@path,-id" which is resolved by the system
+// for the language of the user, services.msc shows it translated.
+func isMUIString(s string) bool {
+	return strings.HasPrefix(s, "@") && strings.Contains(s, ",-")
+}
+
+// localize returns value of the first matched locale: exact locale ("de-DE") or its language ("de"),
+// it returns fallback if nothing matches.
+func localize(values map[string]string, locales []string, fallback string) string {
+	if len(values) == 0 {
+		return fallback
+	}
+
+	lower := make(map[string]string, len(values))
+	for k, v := range values {
+		lower[strings.ToLower(k)] = v
+	}
+
+	for _, l := range locales {
+		l = strings.ToLower(l)
+		if v, ok := lower[l]; ok {
+			return v
+		}
+
+		if i := strings.IndexByte(l, '-'); i > 0 {
+			if v, ok := lower[l[:i]]; ok {
+				return v
+			}
+		}
+	}
+	return fallback
+}
+
+// localizeConfig sets display name and description of the locales.
+func localizeConfig(c Config, locales []string) Config {
+	c.DisplayName = localize(c.DisplayNames, locales, c.DisplayName)
+	c.Description = localize(c.Descriptions, locales, c.Description)
+	return c
+}
@@ -0,0 +1,30 @@
+package winsvc
+
+import "testing"
+
+func TestLocalize(t *testing.T) {
+	names := map[string]string{"de": "Mein Dienst", "fr-CA": "Mon service (CA)", "fr": "Mon service"}
+	tests := []struct {
+		locales []string
+		exp     string
+	}{
+		{[]string{"de-AT"}, "Mein Dienst"},
+		{[]string{"fr-ca"}, "Mon service (CA)"},
+		{[]string{"fr-FR"}, "Mon service"},
+		{[]string{"ja-JP", "de-DE"}, "Mein Dienst"},
+		{[]string{"ja-JP"}, "My service"},
+		{nil, "My service"},
+	}
+
+	for _, tt := range tests {
+		if got := localize(names, tt.locales, "My service"); got != tt.exp {
+			t.Errorf("%v exp: %s, got: %s", tt.locales, tt.exp, got)
+		}
+	}
+}
+
+func TestIsMUIString(t *testing.T) {
+	if !isMUIString(`@%SystemRoot%\system32\app.dll,-101`) || isMUIString("@home") {
+		t.Errorf("exp: only indirect string of resource")
+	}
+}
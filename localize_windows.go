@@ -0,0 +1,14 @@
+// +build windows
+
+package winsvc
+
+import "golang.org/x/sys/windows"
+
+// systemLocales returns preferred UI languages of the system, services.msc is used by users of the machine.
+func systemLocales() []string {
+	locales, err := windows.GetSystemPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+	if err != nil {
+		return nil
+	}
+	return locales
+}
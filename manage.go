@@ -0,0 +1,242 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// timeoutWait is a time of waiting the state of service after start or stop.
+const timeoutWait = time.Second * 30
+
+// scm is the manager of the system.
+type scm struct{}
+
+// SCM returns the service control manager of the system.
+func SCM() Manager {
+	return scm{}
+}
+
+func (scm) Install(c Config) error                    { return Install(c) }
+func (scm) Uninstall(name string) error               { return Uninstall(name) }
+func (scm) Start(name string, args ...string) error   { return Start(name, args...) }
+func (scm) Stop(name string) error                    { return Stop(name) }
+func (scm) Restart(name string, args ...string) error { return Restart(name, args...) }
+func (scm) Status(name string) (State, error)         { return Status(name) }
+
+// Start starts the service and waits until it is running.
+// Arguments are passed only to this start, SetStartArgs sets arguments of starts without them (see StartArgs).
+func Start(name string, args ...string) error {
+	return StartContext(context.Background(), name, args...)
+}
+
+// StartContext is Start which waits until context is done, it waits 30s if context has no deadline.
+func StartContext(ctx context.Context, name string, args ...string) error {
+	return StartWaitContext(ctx, name, nil, args...)
+}
+
+// StartWait starts the service and waits until it is running no longer than timeout,
+// progress gets states and checkpoints of starting if it is not nil.
+// It returns ErrStartFailed as soon as the service stops while starting.
+func StartWait(name string, timeout time.Duration, progress func(p Progress), args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return StartWaitContext(ctx, name, progress, args...)
+}
+
+// StartWaitContext is StartWait which waits until context is done.
+func StartWaitContext(ctx context.Context, name string, progress func(p Progress), args ...string) error {
+	return audit("start", name, argsDetails(args), wrapError("start", name, withService(ctx, name, func(s *mgr.Service) error {
+		if err := s.Start(args...); err != nil {
+			return err
+		}
+		return waitState(ctx, s, svc.Running, progress)
+	})))
+}
+
+// Stop stops the service and waits until it is stopped.
+func Stop(name string) error {
+	return StopContext(context.Background(), name)
+}
+
+// StopContext is Stop which waits until context is done, it waits 30s if context has no deadline.
+func StopContext(ctx context.Context, name string) error {
+	return StopWaitContext(ctx, name, nil)
+}
+
+// StopWait stops the service and waits until it is stopped no longer than timeout,
+// progress gets states and checkpoints of stopping if it is not nil.
+func StopWait(name string, timeout time.Duration, progress func(p Progress)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return StopWaitContext(ctx, name, progress)
+}
+
+// StopWaitContext is StopWait which waits until context is done.
+func StopWaitContext(ctx context.Context, name string, progress func(p Progress)) error {
+	return audit("stop", name, "", wrapError("stop", name, withService(ctx, name, func(s *mgr.Service) error {
+		return stopService(ctx, s, progress)
+	})))
+}
+
+// Restart stops the service if it is not stopped and starts it again.
+func Restart(name string, args ...string) error {
+	return RestartContext(context.Background(), name, args...)
+}
+
+// RestartContext is Restart which waits until context is done, it waits 30s for stop and start if context has no deadline.
+func RestartContext(ctx context.Context, name string, args ...string) error {
+	return audit("restart", name, argsDetails(args), wrapError("restart", name, withService(ctx, name, func(s *mgr.Service) error {
+		if err := stopService(ctx, s, nil); err != nil {
+			return err
+		}
+		return startService(ctx, s, args...)
+	})))
+}
+
+// Status returns the current state of the service.
+func Status(name string) (State, error) {
+	return StatusContext(context.Background(), name)
+}
+
+// StatusContext is Status which stops retries of connection to the service manager when context is done.
+func StatusContext(ctx context.Context, name string) (State, error) {
+	var state State
+	err := withService(ctx, name, func(s *mgr.Service) error {
+		status, err := s.Query()
+		if err != nil {
+			return err
+		}
+
+		state = State(status.State)
+		return nil
+	})
+	return state, wrapError("status", name, err)
+}
+
+// WaitForService waits until the service is running, it is used inside run function for soft dependencies
+// which can not be dependencies of the service manager, for example the dependency is started on demand.
+// It uses SCM notifications and returns ErrTimeout if the service is not running in time.
+//
+//	if err := winsvc.WaitForService(ctx, "MSSQLSERVER", time.Minute); err != nil {
+//		return err
+//	}
+func WaitForService(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return wrapError("wait", name, withService(ctx, name, func(s *mgr.Service) error {
+		return waitStateStopped(ctx, s, svc.Running, false, nil)
+	}))
+}
+
+// SetPassword changes password of the account of the service which is stored by the service manager,
+// so scheduled rotation of password does not need reinstall. Running service uses the new password after restart,
+// it is restarted if restart is true. Copies of the password are wiped after the call.
+func SetPassword(name, password string, restart bool) error {
+	pw := passwordFromString(password)
+	defer pw.wipe()
+	return setPassword(name, pw, restart)
+}
+
+// setPassword changes password of the account of the service by password in locked memory.
+func setPassword(name string, pw *password, restart bool) error {
+	if pw.empty() {
+		return wrapError("set password", name, errors.New("password is empty"))
+	}
+
+	ctx := context.Background()
+	return audit("set password", name, "", wrapError("set password", name, withService(ctx, name, func(s *mgr.Service) error {
+		if err := setServicePassword(s.Handle, pw); err != nil {
+			return err
+		}
+
+		if !restart {
+			return nil
+		}
+
+		status, err := s.Query()
+		if err != nil || status.State == svc.Stopped {
+			return err
+		}
+
+		if err := stopService(ctx, s, nil); err != nil {
+			return err
+		}
+		return startService(ctx, s)
+	})))
+}
+
+// withService connects to the service manager and opens the service.
+func withService(ctx context.Context, name string, f func(s *mgr.Service) error) error {
+	m, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := openService(ctx, m, name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return f(s)
+}
+
+// connect connects to the service manager, transient failures are retried by DefaultRetry.
+func connect(ctx context.Context) (*mgr.Mgr, error) {
+	var m *mgr.Mgr
+	err := DefaultRetry.do(ctx, transientSCM, func() (err error) {
+		m, err = mgr.Connect()
+		return err
+	})
+	return m, err
+}
+
+// openService opens the service, transient failures are retried by DefaultRetry.
+func openService(ctx context.Context, m *mgr.Mgr, name string) (*mgr.Service, error) {
+	var s *mgr.Service
+	err := DefaultRetry.do(ctx, transientSCM, func() (err error) {
+		s, err = m.OpenService(name)
+		return err
+	})
+	return s, err
+}
+
+// transientSCM reports whether error of the service manager is transient.
+func transientSCM(err error) bool {
+	return errors.Is(err, windows.RPC_S_SERVER_UNAVAILABLE) || errors.Is(err, windows.RPC_S_SERVER_TOO_BUSY)
+}
+
+// startService starts the service and waits running state.
+func startService(ctx context.Context, s *mgr.Service, args ...string) error {
+	if err := s.Start(args...); err != nil {
+		return err
+	}
+	return waitState(ctx, s, svc.Running, nil)
+}
+
+// stopService stops the service and waits stopped state, progress is called if it is not nil.
+func stopService(ctx context.Context, s *mgr.Service, progress func(p Progress)) error {
+	status, err := s.Query()
+	if err != nil {
+		return err
+	}
+
+	if status.State == svc.Stopped {
+		return nil
+	}
+
+	if status.State != svc.StopPending {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return err
+		}
+	}
+	return waitState(ctx, s, svc.Stopped, progress)
+}
@@ -0,0 +1,12 @@
+package winsvc
+
+// Manager manages services of the service control manager.
+// SCM returns the manager of the system, winsvctest.NewManager returns in-memory manager for tests.
+type Manager interface {
+	Install(c Config) error
+	Uninstall(name string) error
+	Start(name string, args ...string) error
+	Stop(name string) error
+	Restart(name string, args ...string) error
+	Status(name string) (State, error)
+}
@@ -0,0 +1,50 @@
+package winsvc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxNameLength is a maximum length of name and display name of the service.
+const maxNameLength = 256
+
+// reservedNames are names of devices, the name of service is used as name of files (for example manifest of counters).
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// validateName returns error if name of the service is not accepted by the service manager or it is reserved.
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidName)
+	}
+
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidName, maxNameLength)
+	}
+
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return r == '/' || r == '\\' || r < ' ' || strings.ContainsRune(`:*?"<>|`, r)
+	}); i >= 0 {
+		return fmt.Errorf("%w: name %q contains forbidden character %q", ErrInvalidName, name, name[i])
+	}
+
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("%w: name %q has leading or trailing spaces", ErrInvalidName, name)
+	}
+
+	if reservedNames[strings.ToUpper(name)] {
+		return fmt.Errorf("%w: name %q is reserved", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// validateDisplayName returns error if display name of the service is not accepted by the service manager.
+func validateDisplayName(name string) error {
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w: display name is longer than %d characters", ErrInvalidName, maxNameLength)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package winsvc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"app", true},
+		{"app-1.svc_test", true},
+		{"App Service", true},
+		{"", false},
+		{strings.Repeat("a", 257), false},
+		{`a\b`, false},
+		{"a/b", false},
+		{"a:b", false},
+		{"a\tb", false},
+		{" app", false},
+		{"nul", false},
+		{"COM1", false},
+	}
+
+	for _, tt := range tests {
+		err := validateName(tt.name)
+		if tt.valid && err != nil {
+			t.Errorf("%q exp: nil, got: %v", tt.name, err)
+		}
+
+		if !tt.valid && !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q exp: %v, got: %v", tt.name, ErrInvalidName, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// +build windows
+
+package winsvc
+
+import "golang.org/x/sys/windows/svc"
+
+// NetBindChange is a change of network bindings which is sent by the service manager.
+type NetBindChange uint32
+
+// Changes of network bindings.
+const (
+	NetBindAdd     = NetBindChange(svc.NetBindAdd)     // new component for binding
+	NetBindRemove  = NetBindChange(svc.NetBindRemove)  // component for binding has been removed
+	NetBindEnable  = NetBindChange(svc.NetBindEnable)  // disabled binding has been enabled
+	NetBindDisable = NetBindChange(svc.NetBindDisable) // binding has been disabled
+)
+
+// String returns human readable change.
+func (c NetBindChange) String() string {
+	switch c {
+	case NetBindAdd:
+		return "add"
+	case NetBindRemove:
+		return "remove"
+	case NetBindEnable:
+		return "enable"
+	case NetBindDisable:
+		return "disable"
+	}
+	return "unknown"
+}
+
+// OnNetBind registers hook which is called when network bindings are changed,
+// the service accepts NetBind controls only if hooks are registered. Hooks are called in order of controls.
+// Hooks must be registered before Run.
+func (h *Handle) OnNetBind(f func(change NetBindChange)) {
+	h.m.onNetBind = append(h.m.onNetBind, f)
+}
+
+// netBind calls hooks of network bindings.
+func (m *manager) netBind(c NetBindChange) {
+	for _, f := range m.onNetBind {
+		f(c)
+	}
+}
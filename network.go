@@ -0,0 +1,108 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// networkPollInterval is a period of checking readiness of the network.
+const networkPollInterval = time.Second
+
+// errNoAddress is returned when the computer has no routable IP address.
+var errNoAddress = errors.New("no IP address")
+
+// WithNetworkWait is a option to delay run function until the network is ready (see WaitForNetwork),
+// so auto-start service does not race with the network stack at boot.
+// Run function is started anyway after timeout, warning is written to event log then.
+func WithNetworkWait(timeout time.Duration, hosts ...string) option {
+	return func(m *manager) {
+		m.networkWait = timeout
+		m.networkHosts = hosts
+	}
+}
+
+// WaitForNetwork waits until the computer has IP address which is not loopback or link-local
+// and names of hosts are resolved by DNS. It returns error of the last check when timeout is reached
+// or context is done.
+//
+//	if err := winsvc.WaitForNetwork(ctx, time.Minute, "db.example.com"); err != nil {
+//		log.Println(err)
+//	}
+func WaitForNetwork(ctx context.Context, timeout time.Duration, hosts ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return poll(ctx, networkPollInterval, func() error {
+		return networkReady(ctx, hosts)
+	})
+}
+
+// poll calls check every interval until it succeeds or context is done, the last error of check is returned.
+func poll(ctx context.Context, interval time.Duration, check func() error) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("network is not ready: %w", err)
+		case <-t.C:
+		}
+	}
+}
+
+// networkReady checks IP address of the computer and resolving of the hosts.
+func networkReady(ctx context.Context, hosts []string) error {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return err
+	}
+
+	if !hasRoutable(addrs) {
+		return errNoAddress
+	}
+
+	for _, h := range hosts {
+		if _, err := net.DefaultResolver.LookupHost(ctx, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasRoutable reports whether any address is not loopback, link-local or unspecified.
+func hasRoutable(addrs []net.Addr) bool {
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		ip := ipnet.IP
+		if !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified() {
+			return true
+		}
+	}
+	return false
+}
+
+// waitNetwork waits the network before run function if it is set by option.
+func (m *manager) waitNetwork(ctx context.Context) {
+	if m.networkWait <= 0 {
+		return
+	}
+
+	if err := WaitForNetwork(ctx, m.networkWait, m.networkHosts...); err != nil && ctx.Err() == nil {
+		m.report(LevelWarning, codeNetworkTimeout, err.Error())
+	}
+}
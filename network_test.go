@@ -0,0 +1,52 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHasRoutable(t *testing.T) {
+	addr := func(s string) net.Addr {
+		return &net.IPNet{IP: net.ParseIP(s)}
+	}
+
+	tests := []struct {
+		addrs []net.Addr
+		exp   bool
+	}{
+		{nil, false},
+		{[]net.Addr{addr("127.0.0.1"), addr("::1")}, false},
+		{[]net.Addr{addr("169.254.10.1"), addr("fe80::1")}, false},
+		{[]net.Addr{addr("127.0.0.1"), addr("192.168.1.10")}, true},
+	}
+
+	for _, tt := range tests {
+		if got := hasRoutable(tt.addrs); got != tt.exp {
+			t.Errorf("%v exp: %v, got: %v", tt.addrs, tt.exp, got)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	calls := 0
+	err := poll(context.Background(), time.Millisecond, func() error {
+		if calls++; calls < 3 {
+			return errNoAddress
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("exp: 3 calls, got: %d, %v", calls, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if err := poll(ctx, time.Millisecond, func() error { return errNoAddress }); !errors.Is(err, errNoAddress) {
+		t.Errorf("exp: %v, got: %v", errNoAddress, err)
+	}
+}
@@ -0,0 +1,178 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	// pollInterval is interval of querying status if notifications are not available.
+	pollInterval = time.Millisecond * 250
+	// pollNotifyInterval is interval of querying status when notifications are available.
+	// Notifications can be missed between registrations, so status is still queried rarely.
+	pollNotifyInterval = time.Second * 2
+	// alertableSleep is a slice of alertable waiting for checking of the stop.
+	alertableSleep = 100
+
+	notifyMask = windows.SERVICE_NOTIFY_STOPPED | windows.SERVICE_NOTIFY_START_PENDING |
+		windows.SERVICE_NOTIFY_STOP_PENDING | windows.SERVICE_NOTIFY_RUNNING |
+		windows.SERVICE_NOTIFY_CONTINUE_PENDING | windows.SERVICE_NOTIFY_PAUSE_PENDING |
+		windows.SERVICE_NOTIFY_PAUSED | windows.SERVICE_NOTIFY_DELETE_PENDING
+)
+
+// notifyCallback does nothing, APC is only used to wake up the alertable waiting.
+var notifyCallback = windows.NewCallback(func(_ uintptr) uintptr { return 0 })
+
+// notifyStatus returns channel which receives value when status of the service is changed
+// and channel which is closed when notifications are released.
+// NotifyServiceStatusChange queues APC to the thread which has called it,
+// that is why notifications are registered and waited by the locked OS thread.
+// Registration is cancelled only by closing of the handle of the service, so notifications use own handle
+// which is closed before SERVICE_NOTIFY is released. The thread is not unlocked and it is terminated with goroutine,
+// so pending APC is never delivered.
+func notifyStatus(name string, stop <-chan struct{}) (<-chan struct{}, <-chan struct{}, error) {
+	changed := make(chan struct{}, 1)
+	released := make(chan struct{})
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(released)
+		runtime.LockOSThread()
+
+		h, err := openServiceStatus(name)
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		n := &windows.SERVICE_NOTIFY{
+			Version:        windows.SERVICE_NOTIFY_STATUS_CHANGE,
+			NotifyCallback: notifyCallback,
+		}
+		defer func() {
+			windows.CloseServiceHandle(h)
+			runtime.KeepAlive(n)
+		}()
+
+		registered := false
+		for {
+			if err := windows.NotifyServiceStatusChange(h, notifyMask, n); err != nil {
+				if !registered {
+					errc <- err
+				}
+				return
+			}
+
+			if !registered {
+				registered = true
+				errc <- nil
+			}
+
+			for windows.SleepEx(alertableSleep, true) != windows.WAIT_IO_COMPLETION {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	if err := <-errc; err != nil {
+		return nil, released, err
+	}
+	return changed, released, nil
+}
+
+// openServiceStatus opens handle of the service with access of querying status, handle must be closed.
+func openServiceStatus(name string) (windows.Handle, error) {
+	scm, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseServiceHandle(scm)
+	return windows.OpenService(scm, windows.StringToUTF16Ptr(name), windows.SERVICE_QUERY_STATUS)
+}
+
+// waitState waits until the service reaches state, progress is called when state or checkpoint is changed if it is not nil.
+// It waits until context is done or 30s if context has no deadline.
+// It returns ErrStartFailed if the service has stopped while it is waited to run.
+// It uses SCM notifications and falls back to polling if they are not available.
+func waitState(ctx context.Context, s *mgr.Service, state svc.State, progress func(p Progress)) error {
+	return waitStateStopped(ctx, s, state, state == svc.Running, progress)
+}
+
+// waitStateStopped is waitState which fails on stopped service only if failStopped is true.
+func waitStateStopped(ctx context.Context, s *mgr.Service, state svc.State, failStopped bool, progress func(p Progress)) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeoutWait)
+		defer cancel()
+	}
+
+	stop := make(chan struct{})
+	changed, released, errNotify := notifyStatus(s.Name, stop)
+	defer func() {
+		close(stop)
+		<-released
+	}()
+
+	interval := pollNotifyInterval
+	if errNotify != nil {
+		interval = pollInterval
+	}
+
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+	begin := time.Now()
+
+	var last svc.Status
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return err
+		}
+
+		if progress != nil && (status.State != last.State || status.CheckPoint != last.CheckPoint) {
+			progress(Progress{
+				State:      State(status.State),
+				CheckPoint: status.CheckPoint,
+				WaitHint:   time.Duration(status.WaitHint) * time.Millisecond,
+			})
+		}
+		last = status
+
+		if status.State == state {
+			return nil
+		}
+
+		if failStopped && status.State == svc.Stopped {
+			return fmt.Errorf("%w: exit code %d, service-specific exit code %d",
+				ErrStartFailed, status.Win32ExitCode, status.ServiceSpecificExitCode)
+		}
+
+		select {
+		case <-changed:
+		case <-poll.C:
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w: service has not been %s in %s, current state is %s",
+				ErrTimeout, State(state), time.Since(begin).Round(time.Second), State(status.State))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package winsvc
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// progressInterval is a period of printing of time left while state of the service is waited.
+const progressInterval = time.Second * 5
+
+// output is output of actions of the command line. Results (status, config, list) are written to stdout,
+// messages and errors are written to stderr with level prefix, so results can be piped.
+type output struct {
+	out     io.Writer // stdout
+	err     io.Writer // stderr
+	verbose bool      // debug messages are written
+	quiet   bool      // only results and errors are written
+}
+
+// Write writes result to stdout.
+func (o *output) Write(p []byte) (int, error) {
+	return o.out.Write(p)
+}
+
+// debugf writes debug message in verbose mode.
+func (o *output) debugf(format string, args ...interface{}) {
+	if o.verbose {
+		o.message("DEBUG", format, args...)
+	}
+}
+
+// infof writes message unless quiet mode.
+func (o *output) infof(format string, args ...interface{}) {
+	if !o.quiet {
+		o.message("INFO", format, args...)
+	}
+}
+
+// warnf writes warning unless quiet mode.
+func (o *output) warnf(format string, args ...interface{}) {
+	if !o.quiet {
+		o.message("WARNING", format, args...)
+	}
+}
+
+// errorf writes error in any mode.
+func (o *output) errorf(format string, args ...interface{}) {
+	o.message("ERROR", format, args...)
+}
+
+// message writes message with level prefix to stderr.
+func (o *output) message(level, format string, args ...interface{}) {
+	fmt.Fprintf(o.err, "["+level+"] "+format+"\n", args...)
+}
+
+// progress prints progress of waiting of state: changes of state and checkpoint at once and time left every interval.
+// It warns once when checkpoint has not changed longer than wait hint, so operator sees that the service can be hung.
+// Returned stop function stops printing.
+func (o *output) progress(c clock, timeout, interval time.Duration) (func(p Progress), func()) {
+	var (
+		mu      sync.Mutex
+		last    Progress
+		begin   = c.Now()
+		changed = begin
+		warned  bool
+	)
+
+	left := func(now time.Time) time.Duration {
+		if d := timeout - now.Sub(begin); d > 0 {
+			return d.Round(time.Second)
+		}
+		return 0
+	}
+
+	update := func(p Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := c.Now()
+		last, changed, warned = p, now, false
+		o.infof("%s, %s left", p, left(now))
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case <-done:
+				return
+			case <-c.After(interval):
+			}
+
+			mu.Lock()
+			now := c.Now()
+			switch stalled := now.Sub(changed); {
+			case last.WaitHint > 0 && stalled > last.WaitHint && !warned:
+				warned = true
+				o.warnf("%s: checkpoint %d has not changed for %s (wait hint %s), service can be hung",
+					last.State, last.CheckPoint, stalled.Round(time.Second), last.WaitHint)
+			case last.State == 0:
+				o.infof("waiting, %s left", left(now))
+			default:
+				o.infof("%s, %s left", last, left(now))
+			}
+			mu.Unlock()
+		}
+	}()
+
+	return update, func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,60 @@
+package winsvc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		verbose, quiet bool
+		exp            string
+	}{
+		{false, false, "[INFO] info\n[WARNING] warn\n[ERROR] error\n"},
+		{true, false, "[DEBUG] debug\n[INFO] info\n[WARNING] warn\n[ERROR] error\n"},
+		{false, true, "[ERROR] error\n"},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		o := &output{out: &out, err: &errOut, verbose: tt.verbose, quiet: tt.quiet}
+		o.debugf("debug")
+		o.infof("info")
+		o.warnf("warn")
+		o.errorf("error")
+		o.Write([]byte("result\n"))
+
+		if got := errOut.String(); got != tt.exp {
+			t.Errorf("exp: %q, got: %q", tt.exp, got)
+		}
+		if got := out.String(); got != "result\n" {
+			t.Errorf("exp: result, got: %q", got)
+		}
+	}
+}
+
+func TestOutput_Progress(t *testing.T) {
+	var errOut bytes.Buffer
+	o := &output{out: &bytes.Buffer{}, err: &errOut}
+	c := newFakeClock()
+
+	update, stop := o.progress(c, time.Second*30, time.Second*5)
+	c.WaitTimers(1)
+	c.Advance(time.Second * 5)
+	c.WaitTimers(1)
+	update(Progress{State: StopPending, CheckPoint: 1, WaitHint: time.Second * 4})
+	c.Advance(time.Second * 5)
+	c.WaitTimers(1)
+	c.Advance(time.Second * 5)
+	c.WaitTimers(1)
+	stop()
+
+	exp := "[INFO] waiting, 25s left\n" +
+		"[INFO] stop pending (checkpoint 1, wait hint 4s), 25s left\n" +
+		"[WARNING] stop pending: checkpoint 1 has not changed for 5s (wait hint 4s), service can be hung\n" +
+		"[INFO] stop pending (checkpoint 1, wait hint 4s), 15s left\n"
+	if got := errOut.String(); got != exp {
+		t.Errorf("exp: %q, got: %q", exp, got)
+	}
+}
@@ -0,0 +1,233 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// servicesKey is a registry key of services.
+const servicesKey = `SYSTEM\CurrentControlSet\Services\`
+
+// Parameters is a registry key of parameters of the service.
+// It is HKLM\SYSTEM\CurrentControlSet\Services\<name>\Parameters, the usual place of settings of windows services.
+type Parameters struct {
+	key registry.Key
+}
+
+// OpenParameters opens parameters of the service, key is created if write is true.
+func OpenParameters(name string, write bool) (*Parameters, error) {
+	path := servicesKey + name + `\Parameters`
+	if !write {
+		k, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+		if err != nil {
+			return nil, err
+		}
+		return &Parameters{key: k}, nil
+	}
+
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	return &Parameters{key: k}, nil
+}
+
+// Close closes the key.
+func (p *Parameters) Close() error {
+	return p.key.Close()
+}
+
+// String returns string value, environment variables of REG_EXPAND_SZ value are expanded.
+func (p *Parameters) String(name string) (string, error) {
+	v, typ, err := p.key.GetStringValue(name)
+	if err != nil {
+		return "", err
+	}
+
+	if typ == registry.EXPAND_SZ {
+		return registry.ExpandString(v)
+	}
+	return v, nil
+}
+
+// SetString sets string value.
+func (p *Parameters) SetString(name, value string) error {
+	return p.key.SetStringValue(name, value)
+}
+
+// Int returns DWORD or QWORD value.
+func (p *Parameters) Int(name string) (int64, error) {
+	v, _, err := p.key.GetIntegerValue(name)
+	return int64(v), err
+}
+
+// SetInt sets DWORD value or QWORD value if it does not fit DWORD.
+func (p *Parameters) SetInt(name string, value int64) error {
+	if value >= 0 && value <= math.MaxUint32 {
+		return p.key.SetDWordValue(name, uint32(value))
+	}
+	return p.key.SetQWordValue(name, uint64(value))
+}
+
+// Strings returns REG_MULTI_SZ value.
+func (p *Parameters) Strings(name string) ([]string, error) {
+	v, _, err := p.key.GetStringsValue(name)
+	return v, err
+}
+
+// SetStrings sets REG_MULTI_SZ value.
+func (p *Parameters) SetStrings(name string, value []string) error {
+	return p.key.SetStringsValue(name, value)
+}
+
+// Secret returns value which is encrypted by DPAPI of the machine.
+func (p *Parameters) Secret(name string) (Secret, error) {
+	b, _, err := p.key.GetBinaryValue(name)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := unprotect(b)
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s: %w", name, err)
+	}
+	return Secret(v), nil
+}
+
+// SetSecret encrypts value by DPAPI of the machine and sets it as binary value, so it is not stored as plain text.
+// Any account of the machine can decrypt the value, so only SYSTEM, administrators, the account and SID of the service
+// have access to key Parameters after install (see protectParameters).
+func (p *Parameters) SetSecret(name string, value Secret) error {
+	b, err := protect([]byte(value))
+	if err != nil {
+		return fmt.Errorf("encrypt %s: %w", name, err)
+	}
+	return p.key.SetBinaryValue(name, b)
+}
+
+// Delete deletes value.
+func (p *Parameters) Delete(name string) error {
+	return p.key.DeleteValue(name)
+}
+
+// set sets value by its type, it is used for seeding parameters from Config.
+func (p *Parameters) set(name string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return p.SetString(name, v)
+	case Secret:
+		return p.SetSecret(name, v)
+	case int:
+		return p.SetInt(name, int64(v))
+	case int64:
+		return p.SetInt(name, v)
+	case uint32:
+		return p.SetInt(name, int64(v))
+	case float64: // number of json
+		if v != math.Trunc(v) {
+			return fmt.Errorf("parameter %s: number %v is not integer", name, v)
+		}
+		return p.SetInt(name, int64(v))
+	case bool:
+		if v {
+			return p.SetInt(name, 1)
+		}
+		return p.SetInt(name, 0)
+	case []string:
+		return p.SetStrings(name, v)
+	case []interface{}: // array of json
+		ss := make([]string, len(v))
+		for i, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("parameter %s: unsupported type %T of element %d", name, e, i)
+			}
+			ss[i] = s
+		}
+		return p.SetStrings(name, ss)
+	}
+	return fmt.Errorf("parameter %s: unsupported type %T", name, value)
+}
+
+// seedParameters writes parameters of the service.
+func seedParameters(name string, params map[string]interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	p, err := OpenParameters(name, true)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	for k, v := range params {
+		if err := p.set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protectParameters creates key Parameters of the service with protected access: full control of SYSTEM and administrators,
+// read and write of the account and SID of the service. Users can read the key by default, so they could decrypt secrets
+// which are encrypted by DPAPI of the machine.
+func protectParameters(name, account string) error {
+	path := servicesKey + name + `\Parameters`
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return err
+	}
+	k.Close()
+
+	aces, err := serviceACEs(name, account, "CI", "KRKW")
+	if err != nil {
+		return err
+	}
+	return setDACL(`MACHINE\`+path, windows.SE_REGISTRY_KEY, "D:P(A;CI;KA;;;SY)(A;CI;KA;;;BA)"+aces)
+}
+
+// protect encrypts data by DPAPI with the key of the machine.
+func protect(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	err := windows.CryptProtectData(&in, nil, nil, 0, nil,
+		windows.CRYPTPROTECT_UI_FORBIDDEN|windows.CRYPTPROTECT_LOCAL_MACHINE, &out)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return copyBlob(out), nil
+}
+
+// unprotect decrypts data by DPAPI.
+func unprotect(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return copyBlob(out), nil
+}
+
+// copyBlob copies data of blob which is allocated by windows.
+func copyBlob(b windows.DataBlob) []byte {
+	data := make([]byte, b.Size)
+	copy(data, (*[1 << 30]byte)(unsafe.Pointer(b.Data))[:b.Size:b.Size])
+	return data
+}
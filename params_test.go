@@ -0,0 +1,38 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProtect(t *testing.T) {
+	data := []byte("secret")
+	enc, err := protect(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(enc, data) {
+		t.Errorf("exp: encrypted data")
+	}
+
+	got, err := unprotect(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Errorf("exp: %s, got: %s", data, got)
+	}
+}
+
+func TestParameters_SetInvalid(t *testing.T) {
+	var p Parameters // values are checked before write
+	for _, v := range []interface{}{1.5, []interface{}{"a", 1}, struct{}{}} {
+		if err := p.set("value", v); err == nil {
+			t.Errorf("exp: error of %v", v)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// password is a password of the account in locked memory, it is not swapped to disk and it is wiped after use.
+// Memory is allocated outside of the Go heap, so it is not moved or copied by the runtime.
+// Go strings can not be wiped, so password is never converted to string inside the package.
+type password struct {
+	addr uintptr  // memory of VirtualAlloc, it is zero if allocation has failed
+	buf  []uint16 // UTF-16 with terminating zero
+}
+
+// newPassword copies UTF-16 password into locked memory.
+func newPassword(p []uint16) *password {
+	n := uintptr(len(p)+1) * 2
+	addr, err := windows.VirtualAlloc(0, n, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil {
+		// memory of the Go heap is not locked, password is still wiped after use
+		buf := make([]uint16, len(p)+1)
+		copy(buf, p)
+		return &password{buf: buf}
+	}
+
+	// locking is the best effort, the limit of locked pages of the process can be exceeded
+	windows.VirtualLock(addr, n)
+	buf := (*[1 << 28]uint16)(*(*unsafe.Pointer)(unsafe.Pointer(&addr)))[: len(p)+1 : len(p)+1]
+	copy(buf, p)
+	return &password{addr: addr, buf: buf}
+}
+
+// passwordFromString returns password of string, temporary copies are wiped.
+func passwordFromString(s string) *password {
+	runes := []rune(s)
+	p := utf16.Encode(runes)
+	defer wipeRunes(runes)
+	defer wipeUint16(p)
+	return newPassword(p)
+}
+
+// empty reports whether password is empty.
+func (p *password) empty() bool {
+	return p == nil || len(p.buf) <= 1
+}
+
+// ptr returns pointer to zero terminated password.
+func (p *password) ptr() *uint16 {
+	return &p.buf[0]
+}
+
+// wipe zeroes, unlocks and frees memory of the password.
+func (p *password) wipe() {
+	if p == nil || len(p.buf) == 0 {
+		return
+	}
+
+	wipeUint16(p.buf)
+	if p.addr != 0 {
+		windows.VirtualUnlock(p.addr, uintptr(len(p.buf)*2))
+		windows.VirtualFree(p.addr, 0, windows.MEM_RELEASE)
+		p.addr = 0
+	}
+	p.buf = nil
+}
+
+// accountPassword returns account and password of config, password of Config.PasswordCredential
+// is copied from Credential Manager and password of Config.PasswordStdin is read from stdin directly into locked memory.
+// Password must be wiped.
+func accountPassword(c Config) (string, *password, error) {
+	if c.Password == "" && c.PasswordCredential == "" && c.PasswordStdin {
+		pw, err := readPasswordLine(os.Stdin)
+		return c.Account, pw, err
+	}
+
+	if c.Password != "" || c.PasswordCredential == "" {
+		return c.Account, passwordFromString(c.Password), nil
+	}
+
+	var (
+		user string
+		pw   *password
+	)
+	err := readCredential(c.PasswordCredential, func(u string, blob []uint16) {
+		user, pw = u, newPassword(blob)
+		wipeUint16(blob)
+	})
+	if err != nil {
+		if errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return "", nil, &Error{Err: err, Remedy: "store credential " + c.PasswordCredential + " by cmdkey /generic"}
+		}
+		return "", nil, err
+	}
+
+	if c.Account == "" {
+		return user, pw, nil
+	}
+	return c.Account, pw, nil
+}
+
+// setServicePassword changes password of the account of the service, empty password is not changed.
+func setServicePassword(h windows.Handle, p *password) error {
+	if p.empty() {
+		return nil
+	}
+	return windows.ChangeServiceConfig(h, windows.SERVICE_NO_CHANGE, windows.SERVICE_NO_CHANGE,
+		windows.SERVICE_NO_CHANGE, nil, nil, nil, nil, nil, p.ptr(), nil)
+}
+
+// readPasswordLine reads password from the first line of r, read bytes are wiped.
+func readPasswordLine(r io.Reader) (*password, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadSlice('\n')
+	defer wipeBytes(line) // line is the buffer of reader
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+
+	runes := make([]rune, 0, len(line))
+	defer func() { wipeRunes(runes) }()
+	for b := line; len(b) > 0; {
+		r, n := utf8.DecodeRune(b)
+		runes = append(runes, r)
+		b = b[n:]
+	}
+
+	p := utf16.Encode(runes)
+	defer wipeUint16(p)
+	return newPassword(p), nil
+}
+
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func wipeRunes(r []rune) {
+	for i := range r {
+		r[i] = 0
+	}
+}
+
+func wipeUint16(p []uint16) {
+	for i := range p {
+		p[i] = 0
+	}
+}
@@ -0,0 +1,31 @@
+// +build windows
+
+package winsvc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestReadPasswordLine(t *testing.T) {
+	pw, err := readPasswordLine(strings.NewReader("sécret\r\nnext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := windows.UTF16ToString(pw.buf); got != "sécret" {
+		t.Errorf("exp: %s, got: %s", "sécret", got)
+	}
+
+	if pw.addr == 0 {
+		t.Errorf("exp: password outside of the Go heap")
+	}
+
+	// memory is freed by wipe, so its content is not checked after it
+	pw.wipe()
+	if !pw.empty() || pw.addr != 0 {
+		t.Errorf("exp: empty password after wipe")
+	}
+}
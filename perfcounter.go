@@ -0,0 +1,314 @@
+// +build windows
+
+package winsvc
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// perfManifestValue is a value of registry key of the service with path of the loaded counters manifest.
+const perfManifestValue = "WinsvcPerfManifest"
+
+const (
+	perfCountersetSingleInstance = 0
+	perfDetailNovice             = 100
+	perfCounterLargeRawcount     = 0x00010100
+	perfCounterBulkCount         = 0x10410500
+)
+
+var (
+	procPerfStartProvider                  = modadvapi32.NewProc("PerfStartProvider")
+	procPerfStopProvider                   = modadvapi32.NewProc("PerfStopProvider")
+	procPerfSetCounterSetInfo              = modadvapi32.NewProc("PerfSetCounterSetInfo")
+	procPerfCreateInstance                 = modadvapi32.NewProc("PerfCreateInstance")
+	procPerfSetULongLongCounterValue       = modadvapi32.NewProc("PerfSetULongLongCounterValue")
+	procPerfIncrementULongLongCounterValue = modadvapi32.NewProc("PerfIncrementULongLongCounterValue")
+)
+
+// WithCounters is a option to register performance counters of the service at install,
+// counters are shown in PerfMon in the set with name of the service. Use OpenCounters to update them.
+func WithCounters(counters ...Counter) option {
+	return func(m *manager) {
+		m.config.Counters = append(m.config.Counters, counters...)
+	}
+}
+
+// Counters updates values of performance counters of the service.
+type Counters struct {
+	provider uintptr
+	instance uintptr
+	mu       sync.Mutex
+}
+
+// perfCountersetInfo is PERF_COUNTERSET_INFO structure.
+type perfCountersetInfo struct {
+	CounterSetGUID windows.GUID
+	ProviderGUID   windows.GUID
+	NumCounters    uint32
+	InstanceType   uint32
+}
+
+// perfCounterInfo is PERF_COUNTER_INFO structure.
+type perfCounterInfo struct {
+	CounterID   uint32
+	Type        uint32
+	Attrib      uint64
+	Size        uint32
+	DetailLevel uint32
+	Scale       int32
+	Offset      uint32
+}
+
+// OpenCounters starts provider of performance counters of the service which have been registered at install.
+func OpenCounters(name string, counters ...Counter) (*Counters, error) {
+	providerGUID, setGUID := perfGUIDs(name)
+
+	c := &Counters{}
+	if r, _, _ := procPerfStartProvider.Call(uintptr(unsafe.Pointer(&providerGUID)), 0, uintptr(unsafe.Pointer(&c.provider))); r != 0 {
+		return nil, windows.Errno(r)
+	}
+
+	// template is PERF_COUNTERSET_INFO followed by PERF_COUNTER_INFO of every counter
+	info := perfCountersetInfo{CounterSetGUID: setGUID, ProviderGUID: providerGUID, NumCounters: uint32(len(counters)), InstanceType: perfCountersetSingleInstance}
+	template := make([]byte, unsafe.Sizeof(info)+uintptr(len(counters))*unsafe.Sizeof(perfCounterInfo{}))
+	*(*perfCountersetInfo)(unsafe.Pointer(&template[0])) = info
+	for i, counter := range counters {
+		off := unsafe.Sizeof(info) + uintptr(i)*unsafe.Sizeof(perfCounterInfo{})
+		*(*perfCounterInfo)(unsafe.Pointer(&template[off])) = perfCounterInfo{
+			CounterID:   counter.ID,
+			Type:        counter.Type.perfType(),
+			Size:        8,
+			DetailLevel: perfDetailNovice,
+			Offset:      uint32(i * 8),
+		}
+	}
+
+	if r, _, _ := procPerfSetCounterSetInfo.Call(c.provider, uintptr(unsafe.Pointer(&template[0])), uintptr(len(template))); r != 0 {
+		c.Close()
+		return nil, windows.Errno(r)
+	}
+
+	instance, _, err := procPerfCreateInstance.Call(c.provider, uintptr(unsafe.Pointer(&setGUID)),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(name))), 0)
+	if instance == 0 {
+		c.Close()
+		return nil, err
+	}
+	c.instance = instance
+	return c, nil
+}
+
+// Set sets value of the counter, it returns ErrClosed after Close.
+func (c *Counters) Set(id uint32, value uint64) error {
+	return c.call(procPerfSetULongLongCounterValue, id, value)
+}
+
+// Add adds delta to value of the counter, it returns ErrClosed after Close.
+func (c *Counters) Add(id uint32, delta uint64) error {
+	return c.call(procPerfIncrementULongLongCounterValue, id, delta)
+}
+
+// call calls function of the counter with 64-bit value, it is passed by low and high words on 32-bit systems.
+func (c *Counters) call(proc *windows.LazyProc, id uint32, value uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.instance == 0 {
+		return ErrClosed
+	}
+
+	var r uintptr
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		r, _, _ = proc.Call(c.provider, c.instance, uintptr(id), uintptr(uint32(value)), uintptr(value>>32))
+	} else {
+		r, _, _ = proc.Call(c.provider, c.instance, uintptr(id), uintptr(value))
+	}
+
+	if r != 0 {
+		return windows.Errno(r)
+	}
+	return nil
+}
+
+// Close stops provider of counters.
+func (c *Counters) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider == 0 {
+		return nil
+	}
+
+	// instance is freed with the provider
+	r, _, _ := procPerfStopProvider.Call(c.provider)
+	c.provider, c.instance = 0, 0
+	if r != 0 {
+		return windows.Errno(r)
+	}
+	return nil
+}
+
+// perfType returns type of PERF_COUNTER_INFO.
+func (t CounterType) perfType() uint32 {
+	if t == CounterRate {
+		return perfCounterBulkCount
+	}
+	return perfCounterLargeRawcount
+}
+
+// manifestType returns type of counter in manifest.
+func (t CounterType) manifestType() string {
+	if t == CounterRate {
+		return "perf_counter_bulk_count"
+	}
+	return "perf_counter_large_rawcount"
+}
+
+// perfGUIDs returns stable identifiers of provider and set of counters of the service.
+func perfGUIDs(name string) (provider windows.GUID, set windows.GUID) {
+	return nameGUID("winsvc.provider." + strings.ToLower(name)), nameGUID("winsvc.counterset." + strings.ToLower(name))
+}
+
+// nameGUID returns GUID from hash of the name.
+func nameGUID(name string) windows.GUID {
+	h := sha1.Sum([]byte(name))
+	g := windows.GUID{
+		Data1: binary.BigEndian.Uint32(h[0:4]),
+		Data2: binary.BigEndian.Uint16(h[4:6]),
+		Data3: binary.BigEndian.Uint16(h[6:8])&0x0fff | 0x5000, // version 5
+	}
+	copy(g.Data4[:], h[8:16])
+	g.Data4[0] = g.Data4[0]&0x3f | 0x80 // variant RFC 4122
+	return g
+}
+
+type (
+	perfManifest struct {
+		XMLName         xml.Name `xml:"http://schemas.microsoft.com/win/2004/08/events instrumentationManifest"`
+		Instrumentation struct {
+			Counters perfCounters `xml:"counters"`
+		} `xml:"instrumentation"`
+	}
+
+	perfCounters struct {
+		XMLName       xml.Name     `xml:"http://schemas.microsoft.com/win/2005/12/counters counters"`
+		SchemaVersion string       `xml:"schemaVersion,attr"`
+		Provider      perfProvider `xml:"provider"`
+	}
+
+	perfProvider struct {
+		Name                string         `xml:"providerName,attr"`
+		GUID                string         `xml:"providerGuid,attr"`
+		ApplicationIdentity string         `xml:"applicationIdentity,attr"`
+		Type                string         `xml:"providerType,attr"`
+		CounterSet          perfCounterSet `xml:"counterSet"`
+	}
+
+	perfCounterSet struct {
+		GUID        string            `xml:"guid,attr"`
+		URI         string            `xml:"uri,attr"`
+		Name        string            `xml:"name,attr"`
+		Description string            `xml:"description,attr"`
+		Instances   string            `xml:"instances,attr"`
+		Counters    []perfManifestCtr `xml:"counter"`
+	}
+
+	perfManifestCtr struct {
+		ID          uint32 `xml:"id,attr"`
+		URI         string `xml:"uri,attr"`
+		Name        string `xml:"name,attr"`
+		Description string `xml:"description,attr"`
+		Type        string `xml:"type,attr"`
+		DetailLevel string `xml:"detailLevel,attr"`
+	}
+)
+
+// counterManifest returns manifest of counters for lodctr.
+func counterManifest(name, exe string, counters []Counter) ([]byte, error) {
+	providerGUID, setGUID := perfGUIDs(name)
+	var m perfManifest
+	m.Instrumentation.Counters = perfCounters{SchemaVersion: "2.0", Provider: perfProvider{
+		Name:                name,
+		GUID:                providerGUID.String(),
+		ApplicationIdentity: exe,
+		Type:                "userMode",
+		CounterSet: perfCounterSet{
+			GUID:        setGUID.String(),
+			URI:         "Winsvc." + name,
+			Name:        name,
+			Description: "Counters of service " + name,
+			Instances:   "single",
+		},
+	}}
+
+	for _, c := range counters {
+		set := &m.Instrumentation.Counters.Provider.CounterSet
+		set.Counters = append(set.Counters, perfManifestCtr{
+			ID:          c.ID,
+			URI:         fmt.Sprintf("Winsvc.%s.%d", name, c.ID),
+			Name:        c.Name,
+			Description: c.Description,
+			Type:        c.Type.manifestType(),
+			DetailLevel: "standard",
+		})
+	}
+
+	b, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// registerCounters writes manifest of counters next to the executable and loads it by lodctr.
+func registerCounters(name, exe string, counters []Counter) error {
+	if len(counters) == 0 {
+		return nil
+	}
+
+	b, err := counterManifest(name, exe, counters)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(filepath.Dir(exe), name+".counters.man")
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return err
+	}
+
+	if out, err := exec.Command("lodctr", "/m:"+path).CombinedOutput(); err != nil {
+		return fmt.Errorf("lodctr: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return trackArtifacts(name, perfManifestValue, []string{path})
+}
+
+// unregisterCounters unloads manifest of counters which has been loaded at install and removes the file of manifest.
+func unregisterCounters(name string) error {
+	return deleteCounters(trackedArtifacts(name, perfManifestValue))
+}
+
+// deleteCounters unloads manifests of counters and removes their files.
+func deleteCounters(paths []string) error {
+	for _, path := range paths {
+		if out, err := exec.Command("unlodctr", "/m:"+path).CombinedOutput(); err != nil {
+			return fmt.Errorf("unlodctr: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNameGUID(t *testing.T) {
+	a, b := nameGUID("test"), nameGUID("test")
+	if a != b {
+		t.Errorf("exp: stable guid, got: %s and %s", a, b)
+	}
+
+	if nameGUID("other") == a {
+		t.Errorf("exp: different guid")
+	}
+
+	if a.Data3>>12 != 5 {
+		t.Errorf("exp: version 5, got: %s", a)
+	}
+}
+
+func TestCounterManifest(t *testing.T) {
+	b, err := counterManifest("test", `C:\test.exe`, []Counter{
+		{ID: 1, Name: "Requests", Type: CounterRate},
+		{ID: 2, Name: "Queue", Type: CounterValue},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{
+		`<counters xmlns="http://schemas.microsoft.com/win/2005/12/counters" schemaVersion="2.0">`,
+		`name="Requests" description="" type="perf_counter_bulk_count"`,
+		`name="Queue" description="" type="perf_counter_large_rawcount"`,
+		`applicationIdentity="C:\test.exe"`,
+	} {
+		if !bytes.Contains(b, []byte(exp)) {
+			t.Errorf("exp: %s in\n%s", exp, b)
+		}
+	}
+}
+
+func TestCounters_Closed(t *testing.T) {
+	c := &Counters{}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Set(1, 1<<40); err != ErrClosed {
+		t.Errorf("exp: %v, got: %v", ErrClosed, err)
+	}
+	if err := c.Add(1, 1); err != ErrClosed {
+		t.Errorf("exp: %v, got: %v", ErrClosed, err)
+	}
+}
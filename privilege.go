@@ -0,0 +1,86 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// changeNotifyPrivilege is a privilege of traversal which is always kept, like the service manager keeps it.
+const changeNotifyPrivilege = "SeChangeNotifyPrivilege"
+
+// DropPrivileges removes privileges from token of the process except kept ones and SeChangeNotifyPrivilege,
+// removed privileges can not be enabled again for the remainder of the process life.
+// It is called from run function after initialization, for example after binding of the privileged port.
+//
+//	if err := winsvc.DropPrivileges("SeImpersonatePrivilege"); err != nil {
+//		return err
+//	}
+func DropPrivileges(keep ...string) error {
+	luids := make([]windows.LUID, 0, len(keep)+1)
+	for _, name := range append([]string{changeNotifyPrivilege}, keep...) {
+		var luid windows.LUID
+		if err := windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr(name), &luid); err != nil {
+			return fmt.Errorf("privilege %s: %w", name, err)
+		}
+		luids = append(luids, luid)
+	}
+
+	var token windows.Token
+	err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token)
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	privileges, err := tokenPrivileges(token)
+	if err != nil {
+		return err
+	}
+
+	removed := removedPrivileges(privileges.AllPrivileges(), luids)
+	if len(removed) == 0 {
+		return nil
+	}
+
+	buf := make([]byte, unsafe.Sizeof(windows.Tokenprivileges{})+uintptr(len(removed)-1)*unsafe.Sizeof(windows.LUIDAndAttributes{}))
+	state := (*windows.Tokenprivileges)(unsafe.Pointer(&buf[0]))
+	state.PrivilegeCount = uint32(len(removed))
+	copy(state.AllPrivileges(), removed)
+	return windows.AdjustTokenPrivileges(token, false, state, 0, nil, nil)
+}
+
+// tokenPrivileges returns privileges of the token.
+func tokenPrivileges(token windows.Token) (*windows.Tokenprivileges, error) {
+	n := uint32(256)
+	for {
+		buf := make([]byte, n)
+		err := windows.GetTokenInformation(token, windows.TokenPrivileges, &buf[0], n, &n)
+		if err == nil {
+			return (*windows.Tokenprivileges)(unsafe.Pointer(&buf[0])), nil
+		}
+
+		if !errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) || n <= uint32(len(buf)) {
+			return nil, err
+		}
+	}
+}
+
+// removedPrivileges returns privileges which are not kept with attribute of removing.
+func removedPrivileges(privileges []windows.LUIDAndAttributes, keep []windows.LUID) []windows.LUIDAndAttributes {
+	var removed []windows.LUIDAndAttributes
+next:
+	for _, p := range privileges {
+		for _, k := range keep {
+			if p.Luid == k {
+				continue next
+			}
+		}
+		removed = append(removed, windows.LUIDAndAttributes{Luid: p.Luid, Attributes: windows.SE_PRIVILEGE_REMOVED})
+	}
+	return removed
+}
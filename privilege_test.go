@@ -0,0 +1,32 @@
+// +build windows
+
+package winsvc
+
+import (
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestRemovedPrivileges(t *testing.T) {
+	privileges := []windows.LUIDAndAttributes{
+		{Luid: windows.LUID{LowPart: 23}, Attributes: windows.SE_PRIVILEGE_ENABLED},
+		{Luid: windows.LUID{LowPart: 19}},
+		{Luid: windows.LUID{LowPart: 29}, Attributes: windows.SE_PRIVILEGE_ENABLED},
+	}
+
+	got := removedPrivileges(privileges, []windows.LUID{{LowPart: 23}, {LowPart: 29}})
+	if len(got) != 1 || got[0].Luid.LowPart != 19 || got[0].Attributes != windows.SE_PRIVILEGE_REMOVED {
+		t.Errorf("exp: removed privilege 19, got: %+v", got)
+	}
+
+	if got := removedPrivileges(privileges, nil); len(got) != len(privileges) {
+		t.Errorf("exp: %d, got: %d", len(privileges), len(got))
+	}
+}
+
+func TestDropPrivileges_Unknown(t *testing.T) {
+	if err := DropPrivileges("SeUnknownPrivilege"); err == nil {
+		t.Error("exp: error of unknown privilege")
+	}
+}
@@ -0,0 +1,114 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Diff compares the installed configuration of the service with the desired one and returns fields which differ.
+// Empty fields of the desired configuration are not compared, password is never compared.
+func Diff(name string, desired Config) (Drift, error) {
+	var drift Drift
+	err := withService(context.Background(), name, func(s *mgr.Service) error {
+		installed, err := importConfig(s, name)
+		drift = diffConfig(installed, localizeConfig(desired, systemLocales()))
+		return err
+	})
+	return drift, wrapError("diff", name, err)
+}
+
+// Reconcile applies only fields of the desired configuration which differ from the installed configuration
+// and returns them, so repeated calls converge the service without changes. Running service uses
+// the new configuration after restart.
+func Reconcile(name string, desired Config) (Drift, error) {
+	desired = localizeConfig(desired, systemLocales())
+	var drift Drift
+	err := withService(context.Background(), name, func(s *mgr.Service) error {
+		installed, err := importConfig(s, name)
+		if err != nil {
+			return err
+		}
+
+		if drift = diffConfig(installed, desired); len(drift) == 0 {
+			return nil
+		}
+		return applyDrift(s, installed, desired, drift)
+	})
+	err = wrapError("reconcile", name, err)
+	if len(drift) == 0 && err == nil {
+		return drift, nil
+	}
+	return drift, audit("reconcile", name, drift.String(), err)
+}
+
+// applyDrift changes the fields of configuration of the service which differ.
+func applyDrift(s *mgr.Service, installed, desired Config, drift Drift) error {
+	sc, err := s.Config()
+	if err != nil {
+		return err
+	}
+
+	if drift.has(fieldDisplayName) {
+		sc.DisplayName = desired.DisplayName
+	}
+
+	if drift.has(fieldDescription) {
+		sc.Description = desired.Description
+	}
+
+	if drift.has(fieldStartType) {
+		sc.StartType = desired.StartType
+		if sc.StartType == 0 {
+			sc.StartType = StartAutomatic
+		}
+	}
+
+	if drift.has(fieldDelayedAutoStart) {
+		sc.DelayedAutoStart = desired.DelayedAutoStart
+	}
+
+	if drift.has(fieldDependencies) {
+		sc.Dependencies = desired.Dependencies
+	}
+
+	var pw *password
+	if drift.has(fieldAccount) {
+		var account string
+		if account, pw, err = accountPassword(desired); err != nil {
+			return err
+		}
+		defer pw.wipe()
+		sc.ServiceStartName = accountOrDefault(account)
+	}
+
+	if drift.has(fieldExecutable) || drift.has(fieldArgs) {
+		exe, args := installed.Executable, installed.Args
+		if drift.has(fieldExecutable) {
+			if exe, err = executablePath(desired.Executable); err != nil {
+				return err
+			}
+		}
+
+		if drift.has(fieldArgs) {
+			args = desired.Args
+		}
+		sc.BinaryPathName = windows.ComposeCommandLine(append([]string{exe}, args...))
+	}
+
+	if err := s.UpdateConfig(sc); err != nil {
+		return err
+	}
+
+	if err := setServicePassword(s.Handle, pw); err != nil {
+		return err
+	}
+
+	if drift.has(fieldRecovery) {
+		return setRecovery(s, desired)
+	}
+	return nil
+}
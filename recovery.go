@@ -0,0 +1,80 @@
+// +build windows
+
+package winsvc
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// WithRestartOnFailure is a option to restart the service by the service manager after delay when it fails,
+// failures are panic, exit from run function and not zero exit code (see SetExitCode). It is applied at install.
+func WithRestartOnFailure(delay time.Duration) option {
+	return WithRecoveryActions(RecoveryAction{Type: RecoveryRestart, Delay: int(delay / time.Second)})
+}
+
+// WithRecoveryActions is a option to specify actions of the service manager on the first, second and subsequent failures,
+// the last action is repeated. It is applied at install.
+func WithRecoveryActions(actions ...RecoveryAction) option {
+	return func(m *manager) {
+		m.config.Recovery = actions
+	}
+}
+
+// serviceFailureActionsFlag is SERVICE_FAILURE_ACTIONS_FLAG structure.
+type serviceFailureActionsFlag struct {
+	FailureActionsOnNonCrashFailures int32
+}
+
+// setRecovery sets recovery actions of the service.
+func setRecovery(s *mgr.Service, c Config) error {
+	if len(c.Recovery) == 0 {
+		return nil
+	}
+
+	actions := recoveryActions(c)
+	reset := uint32(c.RecoveryReset)
+	if reset == 0 {
+		reset = defaultRecoveryReset
+	}
+
+	if c.RecoveryCommand != "" {
+		if err := s.SetRecoveryCommand(c.RecoveryCommand); err != nil {
+			return err
+		}
+	}
+
+	if err := s.SetRecoveryActions(actions, reset); err != nil {
+		return err
+	}
+
+	// exit with not zero code is a failure too, not only crash of the process
+	flag := serviceFailureActionsFlag{FailureActionsOnNonCrashFailures: 1}
+	return windows.ChangeServiceConfig2(s.Handle, windows.SERVICE_CONFIG_FAILURE_ACTIONS_FLAG, (*byte)(unsafe.Pointer(&flag)))
+}
+
+// recoveryActions returns recovery actions of the configuration for the service manager.
+func recoveryActions(c Config) []mgr.RecoveryAction {
+	actions := make([]mgr.RecoveryAction, 0, len(c.Recovery))
+	for _, a := range c.Recovery {
+		actions = append(actions, mgr.RecoveryAction{Type: int(a.Type), Delay: time.Duration(a.Delay) * time.Second})
+	}
+	return actions
+}
+
+// sameRecovery reports whether recovery actions are equal.
+func sameRecovery(a, b []mgr.RecoveryAction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Delay != b[i].Delay {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+// +build windows
+
+package winsvc
+
+import (
+	"sync"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+// statusReporter reports status of the service to the service manager only in valid order of states.
+type statusReporter struct {
+	mu      sync.Mutex
+	changes chan<- svc.Status
+	h       windows.Handle // handle of status of own handler of controls, statuses are set by it instead of changes
+	current svc.Status
+}
+
+// newStatusReporter returns reporter of stopped service.
+func newStatusReporter(changes chan<- svc.Status) *statusReporter {
+	return &statusReporter{changes: changes, current: svc.Status{State: svc.Stopped}}
+}
+
+// set reports the status, it returns false if the service can not change state to it.
+func (r *statusReporter) set(s svc.Status) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !State(r.current.State).CanChangeTo(State(s.State)) {
+		return false
+	}
+
+	if s.State == r.current.State && s.Accepts == 0 {
+		s.Accepts = r.current.Accepts
+	}
+	r.send(s)
+	r.current = s
+	return true
+}
+
+// report reports the current status again, for example as answer of interrogate.
+func (r *statusReporter) report() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.send(r.current)
+}
+
+// send passes the status to the service manager. Every status is sent by one way: by package svc
+// or by handle of status of own handler of controls (see serviceControls), so statuses are not reordered.
+func (r *statusReporter) send(s svc.Status) {
+	if r.h == 0 {
+		r.changes <- s
+		return
+	}
+
+	// package svc runs one service in the process, it reports own process too
+	windows.SetServiceStatus(r.h, &windows.SERVICE_STATUS{
+		ServiceType:      windows.SERVICE_WIN32_OWN_PROCESS,
+		CurrentState:     uint32(s.State),
+		ControlsAccepted: uint32(s.Accepts),
+		CheckPoint:       s.CheckPoint,
+		WaitHint:         s.WaitHint,
+	})
+}
+
+// status returns the current status.
+func (r *statusReporter) status() svc.Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// answer responds with the current status to interrogate until done is closed, so the service manager
+// is not blocked while the service is stopping. Other controls (repeated stop, shutdown) are ignored,
+// the stop is not repeated and status is not reported twice. It returns when it has stopped.
+func (r *statusReporter) answer(req <-chan request, done <-chan struct{}) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case c := <-req:
+				if c.Cmd == svc.Interrogate {
+					r.report()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return stopped
+}
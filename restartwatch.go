@@ -0,0 +1,74 @@
+// +build windows
+
+package winsvc
+
+import "context"
+
+// RestartOnChange is a option to restart the service through the service manager when one of the files is changed,
+// for example the binary or configuration is replaced by deployment. The service stops with failure exit code
+// and recovery actions of the service manager start it again, so every recovery action must be restart
+// (see WithRestartOnFailure). Run function is restarted instead in interactive mode or without such recovery actions.
+func RestartOnChange(paths ...string) option {
+	return func(m *manager) {
+		m.restartPaths = append(m.restartPaths, paths...)
+	}
+}
+
+// startRestartWatch starts watching of files which restart the service, watching is stopped when the service is stopped.
+func (m *manager) startRestartWatch() {
+	if len(m.restartPaths) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-m.done
+		cancel()
+	}()
+
+	for _, path := range m.restartPaths {
+		path := path
+		changed := debounce(debounceWatch, func() { m.restartOnChange(path) })
+
+		go func() {
+			if err := watchFile(ctx, path, changed); err != nil {
+				m.report(LevelError, codeWatchFailed, "watch "+path+": "+err.Error())
+			}
+		}()
+	}
+}
+
+// restartOnChange stops the service with failure to be restarted by recovery actions of the service manager
+// or restarts run function in interactive mode and if recovery actions do not restart the service.
+func (m *manager) restartOnChange(path string) {
+	reason := "file " + path + " is changed"
+	if m.interactive {
+		m.requestRestart(reason)
+		return
+	}
+
+	if !m.restartedByRecovery() {
+		m.report(LevelWarning, codeRestarted, "restart run function, recovery actions do not restart the service: "+reason)
+		m.requestRestart(reason)
+		return
+	}
+
+	m.report(LevelInfo, codeRestarted, "restart service by recovery actions: "+reason)
+	m.stopWithFailure()
+}
+
+// restartedByRecovery reports whether every recovery action of the service is restart, so the service manager
+// starts the service again after stop with failure.
+func (m *manager) restartedByRecovery() bool {
+	c, err := m.effectiveConfig()
+	if err != nil || len(c.Recovery) == 0 {
+		return false
+	}
+
+	for _, a := range c.Recovery {
+		if a.Type != RecoveryRestart {
+			return false
+		}
+	}
+	return true
+}
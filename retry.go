@@ -0,0 +1,42 @@
+package winsvc
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy is a policy of retries of transient failures, delay is doubled from MinDelay to MaxDelay.
+type RetryPolicy struct {
+	Attempts int // count of retries, 0 disables them
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// DefaultRetry is a policy of retries of connection to the service manager and opening of services by management functions,
+// the service manager can be busy during boot of the system or refresh of policies.
+var DefaultRetry = RetryPolicy{Attempts: 5, MinDelay: time.Millisecond * 200, MaxDelay: time.Second * 3}
+
+// do calls f until it succeeds or returns not transient error, the last error is returned after attempts are exhausted
+// or context is done.
+func (p RetryPolicy) do(ctx context.Context, transient func(err error) bool, f func() error) error {
+	b := backoff{min: p.MinDelay, max: p.MaxDelay, attempts: p.Attempts}
+	for {
+		err := f()
+		if err == nil || !transient(err) {
+			return err
+		}
+
+		d, ok := b.next()
+		if !ok || p.Attempts <= 0 {
+			return err
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
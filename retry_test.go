@@ -0,0 +1,51 @@
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	var (
+		busy  = errors.New("busy")
+		calls int
+	)
+	p := RetryPolicy{Attempts: 3, MinDelay: time.Millisecond, MaxDelay: time.Millisecond * 2}
+	transient := func(err error) bool { return err == busy }
+
+	err := p.do(context.Background(), transient, func() error {
+		calls++
+		if calls < 3 {
+			return busy
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("exp: success after %d calls, got: %v after %d", 3, err, calls)
+	}
+
+	calls = 0
+	if err := p.do(context.Background(), transient, func() error { calls++; return busy }); err != busy || calls != 4 {
+		t.Errorf("exp: %v after %d calls, got: %v after %d", busy, 4, err, calls)
+	}
+
+	calls = 0
+	fatal := errors.New("fatal")
+	if err := p.do(context.Background(), transient, func() error { calls++; return fatal }); err != fatal || calls != 1 {
+		t.Errorf("exp: %v after %d calls, got: %v after %d", fatal, 1, err, calls)
+	}
+}
+
+func TestRetryPolicy_DoCanceled(t *testing.T) {
+	busy := errors.New("busy")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	p := RetryPolicy{Attempts: 3, MinDelay: time.Hour, MaxDelay: time.Hour}
+	if err := p.do(ctx, func(error) bool { return true }, func() error { calls++; return busy }); err != busy || calls != 1 {
+		t.Errorf("exp: %v after %d calls, got: %v after %d", busy, 1, err, calls)
+	}
+}
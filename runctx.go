@@ -0,0 +1,59 @@
+package winsvc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runContext is a context of run function which knows the end of graceful stop after it is canceled,
+// so shutdown code of the application can budget own operations (see StopDeadline).
+// Deadline of the context is not set, context does not expire by itself.
+type runContext struct {
+	context.Context
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+// runContextKey is a key of value of runContext.
+type runContextKey struct{}
+
+// newRunContext returns context of run function and function which cancels it with deadline of stop.
+func newRunContext() (context.Context, func(deadline time.Time)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &runContext{Context: ctx}
+	return c, func(deadline time.Time) {
+		c.mu.Lock()
+		c.deadline = deadline
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+// Value returns the context itself by runContextKey, so StopDeadline finds it through derived contexts.
+func (c *runContext) Value(key interface{}) interface{} {
+	if key == (runContextKey{}) {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+// StopDeadline returns the end of graceful stop of the service (see TimeoutStop) after context of run function
+// or the context derived from it is canceled by stop, it is not set before stop.
+//
+//	<-ctx.Done()
+//	if d, ok := winsvc.StopDeadline(ctx); ok {
+//		stopCtx, cancel := context.WithDeadline(context.Background(), d)
+//		defer cancel()
+//	}
+func StopDeadline(ctx context.Context) (time.Time, bool) {
+	c, ok := ctx.Value(runContextKey{}).(*runContext)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline, !c.deadline.IsZero()
+}
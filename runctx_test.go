@@ -0,0 +1,35 @@
+package winsvc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStopDeadline(t *testing.T) {
+	ctx, cancel := newRunContext()
+	if _, ok := StopDeadline(ctx); ok {
+		t.Errorf("exp: without deadline before stop")
+	}
+
+	exp := time.Now().Add(time.Second * 20)
+	cancel(exp)
+	<-ctx.Done()
+	if got, ok := StopDeadline(ctx); !ok || !got.Equal(exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	derived, cancelDerived := context.WithCancel(ctx)
+	defer cancelDerived()
+	if got, ok := StopDeadline(derived); !ok || !got.Equal(exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("exp: context without deadline")
+	}
+
+	if _, ok := StopDeadline(context.Background()); ok {
+		t.Errorf("exp: without deadline of other context")
+	}
+}
@@ -0,0 +1,168 @@
+package winsvc
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule is a parsed cron expression: minute hour day-of-month month day-of-week.
+type schedule struct {
+	minute, hour, dom, month, dow uint64 // bit sets of allowed values
+	domAny, dowAny                bool
+}
+
+// parseSchedule parses cron expression of 5 fields, every field is *, number, range a-b, step */n or a-b/n
+// and list of them separated by comma. Day of week is 0-6 (Sunday is 0 or 7).
+func parseSchedule(spec string) (*schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+	for i, f := range fields {
+		set, err := parseField(f, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday is 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &schedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseField returns bit set of values of the field.
+func parseField(f string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value %q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// matchDay reports whether the day matches, like cron it is any of day of month or day of week if both are set.
+func (s *schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// match reports whether the minute of t matches the schedule.
+func (s *schedule) match(t time.Time) bool {
+	return s.month&(1<<uint(t.Month())) != 0 && s.matchDay(t) &&
+		s.hour&(1<<uint(t.Hour())) != 0 && s.minute&(1<<uint(t.Minute())) != 0
+}
+
+// next returns the first matched minute after t, it returns zero time if nothing matches within 5 years.
+// Minutes and hours are stepped in location of t, Truncate would cut them in UTC.
+func (s *schedule) next(t time.Time) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, t.Location())
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// window returns the first matched minute after t and duration of maintenance window,
+// the window is the matched minutes in a row, for example "0-29 3 * * *" is 30 minutes from 3:00.
+func (s *schedule) window(t time.Time) (time.Time, time.Duration) {
+	begin := s.next(t)
+	if begin.IsZero() {
+		return begin, 0
+	}
+
+	d := time.Minute
+	for d < time.Hour*24 && s.match(begin.Add(d)) {
+		d += time.Minute
+	}
+	return begin, d
+}
+
+// windowEnd returns the first not matched minute after the window which contains t, it returns t if t is outside of window.
+func (s *schedule) windowEnd(t time.Time) time.Time {
+	if !s.match(t) {
+		return t
+	}
+
+	m := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	for i := 0; i < 24*60 && s.match(m); i++ {
+		m = m.Add(time.Minute)
+	}
+	return m
+}
+
+// nextRestart returns the time of restart, it is random inside the next maintenance window,
+// so restarts of several machines are spread. The window which contains now is skipped,
+// so the service is restarted once per window.
+func (s *schedule) nextRestart(now time.Time) time.Time {
+	begin, d := s.window(s.windowEnd(now))
+	if begin.IsZero() {
+		return begin
+	}
+	return begin.Add(time.Duration(rand.Int63n(int64(d))))
+}
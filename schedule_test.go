@@ -0,0 +1,91 @@
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("exp: error of %q", spec)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 30, 15, 0, time.UTC) // Wednesday
+	tests := []struct {
+		spec string
+		exp  time.Time
+	}{
+		{"* * * * *", time.Date(2021, 3, 10, 12, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 10, 12, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2021, 3, 11, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 0", time.Date(2021, 3, 14, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2021, 3, 14, 3, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 1", time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"30 2 29 2 *", time.Date(2024, 2, 29, 2, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := s.next(now); !got.Equal(tt.exp) {
+			t.Errorf("%s exp: %v, got: %v", tt.spec, tt.exp, got)
+		}
+	}
+}
+
+func TestSchedule_NextRestart(t *testing.T) {
+	s, err := parseSchedule("0-29 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	begin := time.Date(2021, 3, 11, 3, 0, 0, 0, time.UTC)
+	if _, d := s.window(now); d != time.Minute*30 {
+		t.Errorf("exp: %v, got: %v", time.Minute*30, d)
+	}
+
+	for i := 0; i < 100; i++ {
+		got := s.nextRestart(now)
+		if got.Before(begin) || !got.Before(begin.Add(time.Minute*30)) {
+			t.Fatalf("exp: inside window from %v, got: %v", begin, got)
+		}
+	}
+}
+
+func TestSchedule_NextHalfHourZone(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	s, err := parseSchedule("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Date(2021, 3, 11, 3, 0, 0, 0, ist)
+	if got := s.next(time.Date(2021, 3, 10, 12, 30, 15, 0, ist)); !got.Equal(exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestSchedule_NextRestartInsideWindow(t *testing.T) {
+	s, err := parseSchedule("0-29 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 3, 11, 3, 5, 0, 0, time.UTC) // restart has fired inside window
+	begin := time.Date(2021, 3, 12, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		got := s.nextRestart(now)
+		if got.Before(begin) || !got.Before(begin.Add(time.Minute*30)) {
+			t.Fatalf("exp: inside window from %v, got: %v", begin, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// +build windows
+
+package winsvc
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+// request is a control of the service manager with its data which is copied while the data is valid.
+type request struct {
+	svc.ChangeRequest
+	data eventData
+}
+
+// eventData is data of the control (lpEventData of HandlerEx) which is copied from memory of the service manager.
+type eventData struct {
+	oldTime, newTime time.Time // SERVICE_TIMECHANGE_INFO of time change
+	sessionID        uint32    // WTSSESSION_NOTIFICATION of session change
+}
+
+// copyEventData copies data of the control, data must be valid.
+func copyEventData(cmd svc.Cmd, evdata uintptr) eventData {
+	var d eventData
+	if evdata == 0 {
+		return d
+	}
+
+	// data is memory of the service manager
+	switch cmd {
+	case cmdTimeChange:
+		info := *(**serviceTimeChangeInfo)(unsafe.Pointer(&evdata))
+		d.oldTime, d.newTime = time.Unix(0, info.oldTime.Nanoseconds()), time.Unix(0, info.newTime.Nanoseconds())
+	case svc.SessionChange:
+		d.sessionID = (*(**windows.WTSSESSION_NOTIFICATION)(unsafe.Pointer(&evdata))).SessionID
+	}
+	return d
+}
+
+// newRequest returns control with copied data, data must be valid. EventData of the control is reset,
+// memory of the service manager is not valid after its handler has returned.
+func newRequest(c svc.ChangeRequest) request {
+	r := request{ChangeRequest: c, data: copyEventData(c.Cmd, c.EventData)}
+	r.EventData = 0
+	return r
+}
+
+// serviceControls is handler of controls of the service manager which replaces handler of package svc.
+// Package svc passes controls to Execute after its handler has returned, when data of controls is not valid anymore,
+// and it drops accepted controls which it does not declare (time change, trigger event). So own handler copies data
+// of controls while it is called, and every status of the service is set by handle of status of the own handler.
+var serviceControls struct {
+	once     sync.Once
+	callback uintptr
+
+	mu   sync.Mutex
+	c    chan request
+	done chan struct{}
+}
+
+// controlHandlerEx is HandlerEx of the service, it returns when the control is received or controls are released.
+func controlHandlerEx(ctl, evtype, evdata, context uintptr) uintptr {
+	r := newRequest(svc.ChangeRequest{Cmd: svc.Cmd(ctl), EventType: uint32(evtype), EventData: evdata, Context: context})
+
+	serviceControls.mu.Lock()
+	c, done := serviceControls.c, serviceControls.done
+	serviceControls.mu.Unlock()
+
+	select {
+	case c <- r:
+	case <-done:
+	}
+	return uintptr(windows.NO_ERROR)
+}
+
+// ownControls registers own handler of controls of the service name, it returns handle of status of the service
+// and controls until release is called.
+func ownControls(name string) (windows.Handle, <-chan request, func(), error) {
+	serviceControls.once.Do(func() {
+		serviceControls.callback = windows.NewCallback(controlHandlerEx)
+	})
+
+	c, done := make(chan request), make(chan struct{})
+	serviceControls.mu.Lock()
+	serviceControls.c, serviceControls.done = c, done
+	serviceControls.mu.Unlock()
+
+	h, err := windows.RegisterServiceCtrlHandlerEx(windows.StringToUTF16Ptr(name), serviceControls.callback, 0)
+	if err != nil {
+		close(done)
+		return 0, nil, nil, err
+	}
+	return h, c, func() { close(done) }, nil
+}
+
+// requests returns controls of the service manager until release is called. The service which is run
+// by the service manager owns handler of controls (see serviceControls) and status is set by its handle,
+// otherwise controls of r are passed with copied data.
+func (m *manager) requests(args []string, r <-chan svc.ChangeRequest, status *statusReporter) (<-chan request, func()) {
+	if len(args) > 0 && svc.StatusHandle() != 0 {
+		h, c, release, err := ownControls(args[0])
+		if err == nil {
+			status.h = h
+			return c, release
+		}
+		m.report(LevelWarning, codeControlsFailed, "register handler of controls: "+err.Error())
+	}
+
+	c, done := make(chan request), make(chan struct{})
+	go func() {
+		for {
+			select {
+			case cr := <-r:
+				select {
+				case c <- newRequest(cr):
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return c, func() { close(done) }
+}
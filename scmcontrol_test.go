@@ -0,0 +1,40 @@
+// +build windows
+
+package winsvc
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestManager_RequestsCopyData(t *testing.T) {
+	m := newManager(nil)
+	r := make(chan svc.ChangeRequest)
+	requests, release := m.requests(nil, r, newStatusReporter(nil))
+	defer release()
+
+	before, after := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC), time.Date(2021, 3, 10, 11, 0, 0, 0, time.UTC)
+	info := serviceTimeChangeInfo{newTime: windows.NsecToFiletime(after.UnixNano()), oldTime: windows.NsecToFiletime(before.UnixNano())}
+	r <- svc.ChangeRequest{Cmd: cmdTimeChange, EventData: uintptr(unsafe.Pointer(&info))}
+	req := <-requests
+
+	// memory of the service manager is not valid after the control
+	info = serviceTimeChangeInfo{}
+	if !req.data.oldTime.Equal(before) || !req.data.newTime.Equal(after) {
+		t.Errorf("exp: %v %v, got: %v %v", before, after, req.data.oldTime, req.data.newTime)
+	}
+
+	n := windows.WTSSESSION_NOTIFICATION{SessionID: 3}
+	n.Size = uint32(unsafe.Sizeof(n))
+	r <- svc.ChangeRequest{Cmd: svc.SessionChange, EventType: uint32(SessionLogoff), EventData: uintptr(unsafe.Pointer(&n))}
+	req = <-requests
+
+	n.SessionID = 0
+	if req.data.sessionID != 3 || req.EventData != 0 {
+		t.Errorf("exp: session 3 without event data, got: %d %x", req.data.sessionID, req.EventData)
+	}
+}
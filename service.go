@@ -0,0 +1,125 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"fmt"
+)
+
+// Service is the program which is started and stopped by the service manager, it is like Interface of kardianos/service.
+// Start must not block, work of the service is done in goroutines. Context of Stop is done after timeout of stop.
+type Service interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Controller runs Service and manages the installed service by its configuration, it is like Service of kardianos/service.
+//
+//	c := winsvc.NewController(prg, winsvc.WithConfig(winsvc.Config{Name: "app"}))
+//	if err := c.Run(); err != nil {
+//		log.Fatal(err)
+//	}
+type Controller struct {
+	h        *Handle
+	startErr chan error // error of Service.Start, run function exits with it
+}
+
+// NewController returns controller of the service with options.
+func NewController(s Service, opts ...option) *Controller {
+	c := &Controller{startErr: make(chan error, 1)}
+	c.h = New(c.runService(s), opts...)
+	return c
+}
+
+// runService returns run function which starts s, waits stop and stops s until the end of graceful stop.
+func (c *Controller) runService(s Service) runFunc {
+	return func(ctx context.Context) {
+		if err := s.Start(ctx); err != nil {
+			c.startErr <- fmt.Errorf("start: %w", err)
+			c.h.m.report(LevelError, codeServiceFailed, "start: "+err.Error())
+			return
+		}
+
+		<-ctx.Done()
+		stopCtx := context.Background()
+		if d, ok := StopDeadline(ctx); ok {
+			var cancel context.CancelFunc
+			stopCtx, cancel = context.WithDeadline(stopCtx, d)
+			defer cancel()
+		}
+
+		if err := s.Stop(stopCtx); err != nil {
+			c.h.m.report(LevelError, codeServiceFailed, "stop: "+err.Error())
+		}
+	}
+}
+
+// Run runs the service or command action in console like winsvc.RunE, it is blocked until the service is stopped.
+// Error of Service.Start is returned instead of ErrRunExited.
+func (c *Controller) Run() error {
+	err := c.h.RunE()
+	select {
+	case startErr := <-c.startErr:
+		return startErr
+	default:
+		return err
+	}
+}
+
+// Handle returns handle of the service to register hooks before Run.
+func (c *Controller) Handle() *Handle {
+	return c.h
+}
+
+// Install installs the service by the effective configuration with install hooks.
+func (c *Controller) Install() error {
+	cfg, err := c.h.m.effectiveConfig()
+	if err != nil {
+		return err
+	}
+	return c.h.m.installWithHooks(cfg)
+}
+
+// Uninstall uninstalls the service with install hooks.
+func (c *Controller) Uninstall() error {
+	cfg, err := c.h.m.effectiveConfig()
+	if err != nil {
+		return err
+	}
+	return c.h.m.uninstallWithHooks(cfg, false)
+}
+
+// Start starts the installed service.
+func (c *Controller) Start() error {
+	return c.manage(func(name string) error { return Start(name) })
+}
+
+// Stop stops the installed service.
+func (c *Controller) Stop() error {
+	return c.manage(Stop)
+}
+
+// Restart restarts the installed service.
+func (c *Controller) Restart() error {
+	return c.manage(func(name string) error { return Restart(name) })
+}
+
+// Status returns state of the installed service.
+func (c *Controller) Status() (State, error) {
+	var state State
+	err := c.manage(func(name string) (err error) {
+		state, err = Status(name)
+		return err
+	})
+	return state, err
+}
+
+// manage calls f with name of the service from the effective configuration.
+func (c *Controller) manage(f func(name string) error) error {
+	cfg, err := c.h.m.effectiveConfig()
+	if err != nil {
+		return err
+	}
+	return f(cfg.Name)
+}
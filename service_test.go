@@ -0,0 +1,56 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+type testService struct {
+	calls    []string
+	startErr error
+}
+
+func (s *testService) Start(ctx context.Context) error {
+	s.calls = append(s.calls, "start")
+	return s.startErr
+}
+
+func (s *testService) Stop(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("no deadline")
+	}
+	s.calls = append(s.calls, "stop")
+	return nil
+}
+
+func TestController_Run(t *testing.T) {
+	s := &testService{}
+	c := NewController(s)
+
+	controlScript(t, c.h.m, svc.Stop)
+	if exp := []string{"start", "stop"}; !reflect.DeepEqual(s.calls, exp) {
+		t.Errorf("exp: %v, got: %v", exp, s.calls)
+	}
+}
+
+func TestController_StartError(t *testing.T) {
+	errStart := errors.New("port is busy")
+	s := &testService{startErr: errStart}
+	c := NewController(s)
+
+	c.runService(s)(context.Background())
+	select {
+	case err := <-c.startErr:
+		if !errors.Is(err, errStart) {
+			t.Errorf("exp: %v, got: %v", errStart, err)
+		}
+	default:
+		t.Errorf("exp: error of start")
+	}
+}
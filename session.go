@@ -0,0 +1,119 @@
+// +build windows
+
+package winsvc
+
+import (
+	"errors"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// noSession is returned by WTSGetActiveConsoleSessionId if nobody is logged on the console.
+const noSession = 0xFFFFFFFF
+
+// StartInSession starts the program in the session of the user who is logged on the console
+// and returns identifier of its process, for example tray icon of the service.
+// The service must run as LocalSystem, services run in the isolated session 0 and their windows are not seen by users.
+func StartInSession(path string, args ...string) (int, error) {
+	session := windows.WTSGetActiveConsoleSessionId()
+	if session == noSession {
+		return 0, errors.New("nobody is logged on the console")
+	}
+
+	var token windows.Token
+	if err := windows.WTSQueryUserToken(session, &token); err != nil {
+		return 0, err
+	}
+	defer token.Close()
+
+	var env *uint16
+	if err := windows.CreateEnvironmentBlock(&env, token, false); err != nil {
+		return 0, err
+	}
+	defer windows.DestroyEnvironmentBlock(env)
+
+	si := windows.StartupInfo{Desktop: windows.StringToUTF16Ptr(`winsta0\default`)}
+	si.Cb = uint32(unsafe.Sizeof(si))
+	var pi windows.ProcessInformation
+	cmdLine := windows.ComposeCommandLine(append([]string{path}, args...))
+	err := windows.CreateProcessAsUser(token, nil, windows.StringToUTF16Ptr(cmdLine), nil, nil, false,
+		windows.CREATE_UNICODE_ENVIRONMENT, env, windows.StringToUTF16Ptr(filepath.Dir(path)), &si, &pi)
+	if err != nil {
+		return 0, err
+	}
+
+	windows.CloseHandle(pi.Thread)
+	windows.CloseHandle(pi.Process)
+	return int(pi.ProcessId), nil
+}
+
+// SessionEvent is an event of user session which is sent by the service manager.
+type SessionEvent uint32
+
+// Events of user sessions.
+const (
+	SessionConsoleConnect    = SessionEvent(windows.WTS_CONSOLE_CONNECT)
+	SessionConsoleDisconnect = SessionEvent(windows.WTS_CONSOLE_DISCONNECT)
+	SessionRemoteConnect     = SessionEvent(windows.WTS_REMOTE_CONNECT)
+	SessionRemoteDisconnect  = SessionEvent(windows.WTS_REMOTE_DISCONNECT)
+	SessionLogon             = SessionEvent(windows.WTS_SESSION_LOGON)
+	SessionLogoff            = SessionEvent(windows.WTS_SESSION_LOGOFF)
+	SessionLock              = SessionEvent(windows.WTS_SESSION_LOCK)
+	SessionUnlock            = SessionEvent(windows.WTS_SESSION_UNLOCK)
+)
+
+// String returns human readable event.
+func (e SessionEvent) String() string {
+	switch e {
+	case SessionConsoleConnect:
+		return "console connect"
+	case SessionConsoleDisconnect:
+		return "console disconnect"
+	case SessionRemoteConnect:
+		return "remote connect"
+	case SessionRemoteDisconnect:
+		return "remote disconnect"
+	case SessionLogon:
+		return "logon"
+	case SessionLogoff:
+		return "logoff"
+	case SessionLock:
+		return "lock"
+	case SessionUnlock:
+		return "unlock"
+	}
+	return "unknown"
+}
+
+// SessionChange is a change of user session.
+type SessionChange struct {
+	Event     SessionEvent
+	SessionID uint32
+}
+
+// OnSessionChange registers hook which is called when user session is changed (logon, logoff, lock and etc),
+// the service accepts SessionChange control only if hooks are registered. Hooks are called in order of controls.
+// Hooks must be registered before Run.
+func (h *Handle) OnSessionChange(f func(c SessionChange)) {
+	h.m.onSession = append(h.m.onSession, f)
+}
+
+// OnLogoff registers hook which is called with identifier of session when the user logs off,
+// so resources of the session (mapped drives, tokens of the user) are released promptly.
+// Hooks must be registered before Run.
+func (h *Handle) OnLogoff(f func(sessionID uint32)) {
+	h.OnSessionChange(func(c SessionChange) {
+		if c.Event == SessionLogoff {
+			f(c.SessionID)
+		}
+	})
+}
+
+// sessionChange calls hooks of sessions, identifier of session is copied from WTSSESSION_NOTIFICATION of the control.
+func (m *manager) sessionChange(e SessionEvent, d eventData) {
+	for _, f := range m.onSession {
+		f(SessionChange{Event: e, SessionID: d.sessionID})
+	}
+}
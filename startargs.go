@@ -0,0 +1,60 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// ParamStartArgs is a value of registry key Parameters with arguments of the last start by Start.
+const ParamStartArgs = "StartArgs"
+
+// startArgsKey is a key of arguments of start in context of run function.
+type startArgsKey struct{}
+
+// SetStartArgs writes arguments of start to registry key Parameters of the service, they are used by starts without arguments.
+// Arguments of Start and of the service manager (sc start) are passed only to one start, but written arguments survive automatic starts.
+// Empty arguments are deleted.
+func SetStartArgs(name string, args ...string) error {
+	p, err := OpenParameters(name, true)
+	if err != nil {
+		return wrapError("set start arguments", name, err)
+	}
+	defer p.Close()
+
+	if len(args) == 0 {
+		if err := p.Delete(ParamStartArgs); err != nil && err != registry.ErrNotExist {
+			return wrapError("set start arguments", name, err)
+		}
+		return nil
+	}
+	return wrapError("set start arguments", name, p.SetStrings(ParamStartArgs, args))
+}
+
+// StartArgs returns arguments of start of the service from context of run function: arguments which are passed
+// by the service manager or arguments which are written by SetStartArgs if the service manager has passed nothing.
+func StartArgs(ctx context.Context) []string {
+	args, _ := ctx.Value(startArgsKey{}).([]string)
+	return args
+}
+
+// setStartArgs sets arguments of start from arguments of Execute, the first one is name of the service.
+func (m *manager) setStartArgs(args []string) {
+	if len(args) > 1 {
+		m.startArgs = args[1:]
+		return
+	}
+
+	if m.name == "" {
+		return
+	}
+
+	p, err := OpenParameters(m.name, false)
+	if err != nil {
+		return
+	}
+	defer p.Close()
+	m.startArgs, _ = p.Strings(ParamStartArgs)
+}
@@ -0,0 +1,44 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStartArgs(t *testing.T) {
+	var got []string
+	m := newManager(func(ctx context.Context) { got = StartArgs(ctx) })
+	m.ctxSvc, m.cancelSvc = newRunContext()
+
+	m.setStartArgs([]string{"app", "-mode", "fast"})
+	<-m.runFuncWithNotify()
+
+	exp := []string{"-mode", "fast"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if args := StartArgs(context.Background()); args != nil {
+		t.Errorf("exp: nil, got: %v", args)
+	}
+}
+
+func TestStartArgs_NotReused(t *testing.T) {
+	m := newManager(nil)
+	m.name = "winsvc-test-start-args"
+	m.setStartArgs([]string{m.name, "-once"})
+	if exp := []string{"-once"}; !reflect.DeepEqual(m.startArgs, exp) {
+		t.Errorf("exp: %v, got: %v", exp, m.startArgs)
+	}
+
+	// the next start without arguments does not get arguments of the previous one
+	next := newManager(nil)
+	next.name = m.name
+	next.setStartArgs([]string{next.name})
+	if next.startArgs != nil {
+		t.Errorf("exp: nil, got: %v", next.startArgs)
+	}
+}
@@ -0,0 +1,82 @@
+package winsvc
+
+import (
+	"fmt"
+	"time"
+)
+
+// State is a state of the service, values are equal to svc.State.
+type State uint32
+
+// States of the service.
+const (
+	Stopped         State = 1
+	StartPending    State = 2
+	StopPending     State = 3
+	Running         State = 4
+	ContinuePending State = 5
+	PausePending    State = 6
+	Paused          State = 7
+)
+
+// String returns human readable state of service.
+func (s State) String() string {
+	switch s {
+	case Stopped:
+		return "stopped"
+	case StartPending:
+		return "start pending"
+	case StopPending:
+		return "stop pending"
+	case Running:
+		return "running"
+	case ContinuePending:
+		return "continue pending"
+	case PausePending:
+		return "pause pending"
+	case Paused:
+		return "paused"
+	}
+	return "unknown"
+}
+
+// transitions are states which the service can change to, it is a state machine of the service manager.
+var transitions = map[State][]State{
+	Stopped:         {StartPending},
+	StartPending:    {Running, StopPending, Stopped},
+	Running:         {PausePending, StopPending},
+	PausePending:    {Paused, Running, StopPending},
+	Paused:          {ContinuePending, StopPending},
+	ContinuePending: {Running, Paused, StopPending},
+	StopPending:     {Stopped},
+}
+
+// CanChangeTo reports whether the service can change state to the state.
+// The same state is valid, it reports progress of pending operation.
+func (s State) CanChangeTo(to State) bool {
+	if s == to {
+		return true
+	}
+
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Progress is a status of pending operation of the service.
+type Progress struct {
+	State      State
+	CheckPoint uint32        // it is incremented by the service during long operation
+	WaitHint   time.Duration // estimated time of the operation
+}
+
+// String returns human readable progress.
+func (p Progress) String() string {
+	if p.CheckPoint == 0 && p.WaitHint == 0 {
+		return p.State.String()
+	}
+	return fmt.Sprintf("%s (checkpoint %d, wait hint %s)", p.State, p.CheckPoint, p.WaitHint)
+}
@@ -0,0 +1,44 @@
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgress_String(t *testing.T) {
+	tests := []struct {
+		p   Progress
+		exp string
+	}{
+		{Progress{State: Running}, "running"},
+		{Progress{State: StartPending, CheckPoint: 2, WaitHint: time.Second * 5}, "start pending (checkpoint 2, wait hint 5s)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.exp {
+			t.Errorf("exp: %s, got: %s", tt.exp, got)
+		}
+	}
+}
+
+func TestState_CanChangeTo(t *testing.T) {
+	tests := []struct {
+		from, to State
+		exp      bool
+	}{
+		{Stopped, StartPending, true},
+		{Stopped, Running, false},
+		{StartPending, Running, true},
+		{Running, Running, true},
+		{Running, Paused, false},
+		{StopPending, StopPending, true},
+		{StopPending, Running, false},
+		{StopPending, Stopped, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanChangeTo(tt.to); got != tt.exp {
+			t.Errorf("%s -> %s exp: %t, got: %t", tt.from, tt.to, tt.exp, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// +build windows
+
+package winsvc
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// statusSubkey is a registry key of the service with the extended status.
+const statusSubkey = `\Status`
+
+// Values of the extended status in registry, other values are custom fields.
+const (
+	statusVersion   = "Version"
+	statusPID       = "PID"
+	statusStartTime = "StartTime"
+	statusHealth    = "Health"
+	statusHeartbeat = "Heartbeat"
+	statusState     = "State"
+)
+
+// ExtendedStatus is a status of the running service with details beyond the state of service manager.
+// It is published to HKLM\SYSTEM\CurrentControlSet\Services\<name>\Status while the service is running,
+// so monitoring tools read it by registry or WMI (StdRegProv).
+type ExtendedStatus struct {
+	Version   string
+	PID       int
+	StartTime time.Time
+	Health    string
+	Heartbeat time.Time // time of the last heartbeat, it is zero if heartbeat is not set (see WithHeartbeat)
+	State     string    // state of the service at the last heartbeat
+	Fields    map[string]string
+}
+
+// WithVersion is a option to specify version of the program which is published in the extended status.
+func WithVersion(v string) option {
+	return func(m *manager) {
+		m.version = v
+	}
+}
+
+// createStatusKey creates key of the extended status at install, the account and SID of the service can write it,
+// because the service can not create subkeys of its key. Users can read the status.
+func createStatusKey(name, account string) error {
+	path := servicesKey + name + statusSubkey
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return err
+	}
+	k.Close()
+
+	aces, err := serviceACEs(name, account, "", "KRKW")
+	if err != nil {
+		return err
+	}
+	return setDACL(`MACHINE\`+path, windows.SE_REGISTRY_KEY, "D:P(A;;KA;;;SY)(A;;KA;;;BA)(A;;KR;;;BU)"+aces)
+}
+
+// ReadStatus returns the extended status of the running service.
+// It returns error if the service is not running or it does not publish the status.
+func ReadStatus(name string) (ExtendedStatus, error) {
+	var st ExtendedStatus
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, servicesKey+name+statusSubkey, registry.QUERY_VALUE)
+	if err != nil {
+		return st, wrapError("read status", name, err)
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(-1)
+	if err != nil {
+		return st, wrapError("read status", name, err)
+	}
+
+	for _, n := range names {
+		if n == statusPID {
+			v, _, _ := k.GetIntegerValue(n)
+			st.PID = int(v)
+			continue
+		}
+
+		v, _, _ := k.GetStringValue(n)
+		st.decode(n, v)
+	}
+
+	// key which is created at install is empty while the service is stopped
+	if st.PID == 0 {
+		return st, wrapError("read status", name, registry.ErrNotExist)
+	}
+	return st, nil
+}
+
+// decode sets field of the status by string value of registry.
+func (st *ExtendedStatus) decode(name, value string) {
+	switch name {
+	case statusStartTime:
+		st.StartTime, _ = time.Parse(time.RFC3339, value)
+	case statusVersion:
+		st.Version = value
+	case statusHealth:
+		st.Health = value
+	case statusHeartbeat:
+		st.Heartbeat, _ = time.Parse(time.RFC3339, value)
+	case statusState:
+		st.State = value
+	default:
+		if st.Fields == nil {
+			st.Fields = make(map[string]string)
+		}
+		st.Fields[name] = value
+	}
+}
+
+// statusValues returns string values of the published status, PID is written as DWORD.
+func statusValues(start time.Time, version string, values map[string]string) map[string]string {
+	v := make(map[string]string, len(values)+2)
+	for n, s := range values {
+		v[n] = s
+	}
+
+	v[statusStartTime] = start.UTC().Format(time.RFC3339)
+	if version != "" {
+		v[statusVersion] = version
+	}
+	return v
+}
+
+// extStatus is the extended status of the running service, values are kept until the key is published.
+type extStatus struct {
+	mu     sync.Mutex
+	key    registry.Key // 0 if it is not published
+	name   string
+	values map[string]string
+}
+
+// set sets value of the status and writes it if the status is published.
+func (s *extStatus) set(name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	s.values[name] = value
+
+	if s.key == 0 {
+		return nil
+	}
+	return s.key.SetStringValue(name, value)
+}
+
+// publish opens key of the status of the service and writes values, the key is created if the service
+// has been installed without winsvc (it requires administrator then).
+func (s *extStatus) publish(name, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, servicesKey+name+statusSubkey, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	s.key, s.name = k, name
+
+	if err := k.SetDWordValue(statusPID, uint32(os.Getpid())); err != nil {
+		return err
+	}
+
+	for n, v := range statusValues(time.Now(), version, s.values) {
+		if err := k.SetStringValue(n, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unpublish deletes values of the status, so stale status is not read after stop.
+// The key is kept, the service has no access to create it again.
+func (s *extStatus) unpublish() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key == 0 {
+		return nil
+	}
+	defer func() {
+		s.key.Close()
+		s.key = 0
+	}()
+
+	names, err := s.key.ReadValueNames(-1)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		if err := s.key.DeleteValue(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetHealth sets health of the extended status, for example "ok" or "degraded: database is not available".
+func (h *Handle) SetHealth(health string) error {
+	return h.m.status.set(statusHealth, health)
+}
+
+// SetStatusField sets custom field of the extended status.
+func (h *Handle) SetStatusField(name, value string) error {
+	return h.m.status.set(name, value)
+}
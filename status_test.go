@@ -0,0 +1,46 @@
+// +build windows
+
+package winsvc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatusValues(t *testing.T) {
+	start := time.Date(2021, 3, 10, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	values := statusValues(start, "1.2.0", map[string]string{statusHealth: "ok", "queue": "5"})
+
+	exp := map[string]string{
+		statusStartTime: "2021-03-10T11:00:00Z",
+		statusVersion:   "1.2.0",
+		statusHealth:    "ok",
+		"queue":         "5",
+	}
+	if !reflect.DeepEqual(values, exp) {
+		t.Errorf("exp: %v, got: %v", exp, values)
+	}
+
+	var st ExtendedStatus
+	for n, v := range values {
+		st.decode(n, v)
+	}
+	if !st.StartTime.Equal(start) || st.Version != "1.2.0" || st.Health != "ok" || st.Fields["queue"] != "5" {
+		t.Errorf("exp: decoded values, got: %+v", st)
+	}
+}
+
+func TestExtendedStatus_Decode(t *testing.T) {
+	var st ExtendedStatus
+	st.decode(statusHeartbeat, "2021-03-10T11:00:00Z")
+	st.decode(statusState, "running")
+	st.decode(statusStartTime, "invalid")
+
+	if !st.Heartbeat.Equal(time.Date(2021, 3, 10, 11, 0, 0, 0, time.UTC)) || st.State != "running" {
+		t.Errorf("exp: heartbeat and state, got: %+v", st)
+	}
+	if !st.StartTime.IsZero() || st.Fields != nil {
+		t.Errorf("exp: zero start time without fields, got: %+v", st)
+	}
+}
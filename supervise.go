@@ -0,0 +1,77 @@
+package winsvc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RestartPolicy is a policy of restarts of run function by Supervise.
+type RestartPolicy struct {
+	Restarts int           // count of restarts in a row before the failure is reported to the service manager
+	MinDelay time.Duration // delay of the first restart, it is doubled up to MaxDelay, default is 1s
+	MaxDelay time.Duration // cap of delay, run function which has worked longer is not a crash loop, default is MinDelay
+
+	OnRestart func(err error, delay time.Duration) // it is called before every restart if it is not nil
+}
+
+// Supervise returns run function which restarts r inside the process when it returns before the stop or panics,
+// it is lighter than restart of the service by the service manager. After Restarts in a row it returns,
+// so the failure is reported to the service manager (see DisablePanic).
+//
+//	winsvc.Run(winsvc.Supervise(run, winsvc.RestartPolicy{Restarts: 3, MinDelay: time.Second, MaxDelay: time.Minute}))
+func Supervise(r func(ctx context.Context), p RestartPolicy) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		supervise(ctx, r, p, realClock{})
+	}
+}
+
+// supervise runs r until context is done or restarts are exhausted.
+func supervise(ctx context.Context, r func(ctx context.Context), p RestartPolicy, c clock) {
+	if p.MinDelay <= 0 {
+		p.MinDelay = time.Second
+	}
+	if p.MaxDelay < p.MinDelay {
+		p.MaxDelay = p.MinDelay
+	}
+
+	b := backoff{min: p.MinDelay, max: p.MaxDelay, attempts: p.Restarts}
+	for {
+		start := c.Now()
+		err := runRecovered(ctx, r)
+		if ctx.Err() != nil || p.Restarts <= 0 {
+			return
+		}
+
+		if c.Now().Sub(start) >= p.MaxDelay {
+			b.reset()
+		}
+
+		d, ok := b.next()
+		if !ok {
+			return
+		}
+
+		if p.OnRestart != nil {
+			p.OnRestart(err, d)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.After(d):
+		}
+	}
+}
+
+// runRecovered runs r and returns error of its exit before stop or its panic.
+func runRecovered(ctx context.Context, r func(ctx context.Context)) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+
+	r(ctx)
+	return ErrRunExited
+}
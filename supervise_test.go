@@ -0,0 +1,60 @@
+package winsvc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSupervise_Restarts(t *testing.T) {
+	c := newFakeClock()
+	var (
+		runs   int
+		delays []time.Duration
+	)
+	p := RestartPolicy{
+		Restarts:  2,
+		MinDelay:  time.Second,
+		MaxDelay:  time.Minute,
+		OnRestart: func(err error, d time.Duration) { delays = append(delays, d) },
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		supervise(context.Background(), func(ctx context.Context) {
+			runs++
+			if runs == 2 {
+				panic("failure")
+			}
+		}, p, c)
+	}()
+
+	for i := 0; i < 2; i++ {
+		c.WaitTimers(1)
+		c.Advance(time.Minute)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("exp: restarts are exhausted")
+	}
+
+	if runs != 3 || len(delays) != 2 || delays[0] != time.Second || delays[1] != time.Second*2 {
+		t.Errorf("exp: 3 runs with delays 1s 2s, got: %d %v", runs, delays)
+	}
+}
+
+func TestSupervise_Stop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs int
+	supervise(ctx, func(ctx context.Context) {
+		runs++
+		cancel()
+	}, RestartPolicy{Restarts: 5}, newFakeClock())
+
+	if runs != 1 {
+		t.Errorf("exp: %d, got: %d", 1, runs)
+	}
+}
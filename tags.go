@@ -0,0 +1,105 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// tagsKey is a subkey of registry key of the service with tags.
+const tagsKey = `\Tags`
+
+// WithTags is a option to attach tags to the service at install, they are string values of registry key
+// HKLM\SYSTEM\CurrentControlSet\Services\<name>\Tags, so tools can discover and group services (see FindByTag).
+func WithTags(tags map[string]string) option {
+	return func(m *manager) {
+		m.config = m.config.merge(Config{Tags: tags})
+	}
+}
+
+// writeTags writes tags of the service.
+func writeTags(name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, servicesKey+name+tagsKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	for key, v := range tags {
+		if err := k.SetStringValue(key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServiceTags returns tags of the service, it returns nil if the service has no tags.
+func ServiceTags(name string) (map[string]string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, servicesKey+name+tagsKey, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(names))
+	for _, n := range names {
+		v, _, err := k.GetStringValue(n)
+		if err != nil {
+			continue
+		}
+		tags[n] = v
+	}
+	return tags, nil
+}
+
+// FindByTag returns services which have the tag with the value, any value matches if value is empty.
+// Name of tag is case-insensitive like names of registry values.
+func FindByTag(key, value string) ([]Instance, error) {
+	m, err := connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer m.Disconnect()
+
+	services, err := enumServices(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Instance
+	for _, s := range services {
+		tags, err := ServiceTags(s.Name)
+		if err != nil || !matchTag(tags, key, value) {
+			continue
+		}
+
+		_, args, _ := imagePath(s.Name)
+		list = append(list, Instance{Name: s.Name, DisplayName: s.DisplayName, State: s.State, Args: args})
+	}
+	return list, nil
+}
+
+// matchTag reports whether tags have the tag with the value, any value matches if value is empty.
+func matchTag(tags map[string]string, key, value string) bool {
+	for k, v := range tags {
+		if strings.EqualFold(k, key) {
+			return value == "" || v == value
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+// +build windows
+
+package winsvc
+
+import "testing"
+
+func TestMatchTag(t *testing.T) {
+	tags := map[string]string{"Product": "acme", "role": "worker"}
+	tests := []struct {
+		key, value string
+		exp        bool
+	}{
+		{"product", "acme", true},
+		{"Product", "", true},
+		{"product", "other", false},
+		{"role", "Worker", false},
+		{"zone", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchTag(tags, tt.key, tt.value); got != tt.exp {
+			t.Errorf("%s=%s exp: %v, got: %v", tt.key, tt.value, tt.exp, got)
+		}
+	}
+}
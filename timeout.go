@@ -0,0 +1,60 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// overrideTimeouts overrides timeouts of options by values of registry key Parameters of the service
+// and environment variables, so they are tuned without rebuild. Invalid values are reported and skipped.
+func (m *manager) overrideTimeouts(name string) {
+	var p *Parameters
+	if name != "" {
+		if params, err := OpenParameters(name, false); err == nil {
+			p = params
+			defer p.Close()
+		}
+	}
+
+	for _, t := range []struct {
+		env, param string
+		timeout    *time.Duration
+	}{
+		{EnvTimeoutStop, ParamTimeoutStop, &m.timeout},
+		{EnvTimeoutShutdown, ParamTimeoutShutdown, &m.timeoutShutdown},
+		{EnvTimeoutPreShutdown, ParamTimeoutPreShutdown, &m.timeoutPreShutdown},
+	} {
+		v, ok := os.LookupEnv(t.env)
+		if !ok && p != nil {
+			v, ok = paramTimeout(p, t.param)
+		}
+
+		if !ok {
+			continue
+		}
+
+		d, err := parseTimeout(v)
+		if err != nil {
+			m.report(LevelWarning, codeInvalidTimeout, fmt.Sprintf("%s: %v", t.param, err))
+			continue
+		}
+		*t.timeout = d
+	}
+}
+
+// paramTimeout returns string or DWORD value of the parameter as string.
+func paramTimeout(p *Parameters, name string) (string, bool) {
+	v, err := p.String(name)
+	if err == registry.ErrUnexpectedType {
+		var n int64
+		if n, err = p.Int(name); err == nil {
+			v = fmt.Sprint(n)
+		}
+	}
+	return v, err == nil
+}
@@ -0,0 +1,65 @@
+// +build windows
+
+package winsvc
+
+import (
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+// Controls and accepted controls which are not declared by package svc.
+const (
+	cmdTimeChange   = svc.Cmd(0x10) // SERVICE_CONTROL_TIMECHANGE
+	cmdTriggerEvent = svc.Cmd(0x20) // SERVICE_CONTROL_TRIGGEREVENT
+
+	acceptTimeChange   = svc.Accepted(0x200) // SERVICE_ACCEPT_TIMECHANGE
+	acceptTriggerEvent = svc.Accepted(0x400) // SERVICE_ACCEPT_TRIGGEREVENT
+)
+
+// TriggerEvent is a notification of the service manager that event of trigger of the service has occurred while it is running.
+// The service manager passes only type of the event with the control, data of the trigger (lpEventData) is not defined
+// for SERVICE_CONTROL_TRIGGEREVENT, so it is not surfaced.
+type TriggerEvent struct {
+	EventType uint32 // type of event which is passed by the service manager with the control
+}
+
+// OnTriggerEvent registers hook which is called when event of trigger of the service occurs while it is running,
+// so the service which is started by trigger gets the next events too. The service accepts TriggerEvent control
+// only if hooks are registered. Hooks are called in order of controls.
+// Hooks must be registered before Run.
+func (h *Handle) OnTriggerEvent(f func(e TriggerEvent)) {
+	h.m.onTrigger = append(h.m.onTrigger, f)
+}
+
+// triggerEvent calls hooks of events of triggers.
+func (m *manager) triggerEvent(e TriggerEvent) {
+	for _, f := range m.onTrigger {
+		f(e)
+	}
+}
+
+// serviceTimeChangeInfo is SERVICE_TIMECHANGE_INFO structure, times are FILETIME.
+type serviceTimeChangeInfo struct {
+	newTime windows.Filetime
+	oldTime windows.Filetime
+}
+
+// OnTimeChange registers hook which is called with old and new system time when the time is changed,
+// so timers can be planned again after adjustment of the clock. The service accepts TimeChange control
+// only if hooks are registered. Hooks must be registered before Run.
+func (h *Handle) OnTimeChange(f func(old, new time.Time)) {
+	h.m.onTimeChange = append(h.m.onTimeChange, f)
+}
+
+// timeChange calls hooks of change of system time, times are copied from SERVICE_TIMECHANGE_INFO of the control.
+func (m *manager) timeChange(d eventData) {
+	if d.oldTime.IsZero() && d.newTime.IsZero() {
+		return
+	}
+
+	for _, f := range m.onTimeChange {
+		f(d.oldTime, d.newTime)
+	}
+}
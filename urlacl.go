@@ -0,0 +1,85 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// urlReservationsValue is a value of registry key of the service with created URL reservations.
+const urlReservationsValue = "WinsvcURLReservations"
+
+// WithURLReservation is a option to reserve URL of HTTP.sys (for example http://+:80/app/) for the account of service at install,
+// so the service listens it without administrator rights. The reservation is deleted on uninstall.
+func WithURLReservation(url string) option {
+	return func(m *manager) {
+		m.config.URLReservations = append(m.config.URLReservations, url)
+	}
+}
+
+// AddURLReservation reserves URL of HTTP.sys for the account, it is the same as "netsh http add urlacl".
+func AddURLReservation(url, account string) error {
+	return netshHTTP("add", "urlacl", "url="+url, "user="+account)
+}
+
+// DeleteURLReservation deletes reservation of URL, it is the same as "netsh http delete urlacl".
+func DeleteURLReservation(url string) error {
+	return netshHTTP("delete", "urlacl", "url="+url)
+}
+
+// serviceAccount returns account of the service, virtual account NT SERVICE\<name> is used for LocalSystem.
+func serviceAccount(c Config) string {
+	if c.Account == "" || strings.EqualFold(c.Account, "LocalSystem") {
+		return `NT SERVICE\` + c.Name
+	}
+	return c.Account
+}
+
+// addURLReservations reserves URLs for the account of service.
+func addURLReservations(c Config) error {
+	if len(c.URLReservations) == 0 {
+		return nil
+	}
+
+	account := serviceAccount(c)
+	var added []string
+	for _, url := range c.URLReservations {
+		if err := AddURLReservation(url, account); err != nil {
+			deleteURLReservations(added)
+			return fmt.Errorf("url reservation %s: %w", url, err)
+		}
+		added = append(added, url)
+	}
+
+	if err := trackArtifacts(c.Name, urlReservationsValue, added); err != nil {
+		deleteURLReservations(added)
+		return err
+	}
+	return nil
+}
+
+// removeURLReservations deletes URL reservations which have been created at install.
+func removeURLReservations(name string) error {
+	return deleteURLReservations(trackedArtifacts(name, urlReservationsValue))
+}
+
+// deleteURLReservations deletes URL reservations.
+func deleteURLReservations(urls []string) error {
+	for _, url := range urls {
+		if err := DeleteURLReservation(url); err != nil {
+			return fmt.Errorf("url reservation %s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+// netshHTTP runs command of netsh http.
+func netshHTTP(args ...string) error {
+	out, err := exec.Command("netsh", append([]string{"http"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
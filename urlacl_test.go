@@ -0,0 +1,22 @@
+// +build windows
+
+package winsvc
+
+import "testing"
+
+func TestServiceAccount(t *testing.T) {
+	tests := []struct {
+		c   Config
+		exp string
+	}{
+		{Config{Name: "test"}, `NT SERVICE\test`},
+		{Config{Name: "test", Account: "LocalSystem"}, `NT SERVICE\test`},
+		{Config{Name: "test", Account: `DOMAIN\user`}, `DOMAIN\user`},
+	}
+
+	for _, tt := range tests {
+		if got := serviceAccount(tt.c); got != tt.exp {
+			t.Errorf("exp: %s, got: %s", tt.exp, got)
+		}
+	}
+}
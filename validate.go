@@ -0,0 +1,162 @@
+package winsvc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConfigErrors are all violations of the configuration, it is returned by Config.Validate.
+// It matches ErrInvalidConfig and every violation by errors.Is.
+type ConfigErrors []error
+
+func (e ConfigErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return ErrInvalidConfig.Error() + ": " + strings.Join(s, "; ")
+}
+
+// Is reports whether any violation matches target.
+func (e ConfigErrors) Is(target error) bool {
+	if target == ErrInvalidConfig {
+		return true
+	}
+
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the configuration before install and returns all violations at once, it returns nil if the configuration is valid.
+// Empty name is valid, it is replaced by name of the executable.
+func (c Config) Validate() error {
+	var errs ConfigErrors
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Name != "" {
+		add(validateName(c.Name))
+	}
+	add(validateDisplayName(c.DisplayName))
+	for _, d := range c.Dependencies {
+		if err := validateName(d); err != nil {
+			add(fmt.Errorf("dependency: %w", err))
+		}
+	}
+
+	switch c.StartType {
+	case 0, StartAutomatic, StartManual, StartDisabled:
+	default:
+		add(fmt.Errorf("unknown start type %d", c.StartType))
+	}
+	if c.DelayedAutoStart && (c.StartType == StartManual || c.StartType == StartDisabled) {
+		add(errors.New("delayed start requires automatic start type"))
+	}
+
+	add(validateAccount(c.Account))
+	if (c.Password != "" || c.PasswordStdin) && builtinAccount(c.Account) {
+		add(fmt.Errorf("account %q has no password", accountOrDefault(c.Account)))
+	}
+
+	for i, a := range c.Recovery {
+		if a.Type < RecoveryNone || a.Type > RecoveryRunCommand {
+			add(fmt.Errorf("recovery action %d: unknown type %d", i+1, a.Type))
+		}
+		if a.Delay < 0 {
+			add(fmt.Errorf("recovery action %d: negative delay %d", i+1, a.Delay))
+		}
+		if a.Type == RecoveryRunCommand && c.RecoveryCommand == "" {
+			add(fmt.Errorf("recovery action %d: command is empty", i+1))
+		}
+	}
+	if c.RecoveryReset < 0 {
+		add(fmt.Errorf("negative recovery reset %d", c.RecoveryReset))
+	}
+
+	for _, t := range []struct {
+		name    string
+		seconds int
+	}{{"stop", c.TimeoutStop}, {"shutdown", c.TimeoutShutdown}, {"preshutdown", c.TimeoutPreShutdown}} {
+		if t.seconds < 0 {
+			add(fmt.Errorf("negative timeout of %s %d", t.name, t.seconds))
+		}
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "", "info", "information", "warning", "warn", "error":
+	default:
+		add(fmt.Errorf("unknown log level %q", c.LogLevel))
+	}
+
+	if c.HookTimeout < 0 {
+		add(fmt.Errorf("negative hook timeout %d", c.HookTimeout))
+	}
+	if c.CrashDumpCount < 0 {
+		add(fmt.Errorf("negative count of crash dumps %d", c.CrashDumpCount))
+	}
+
+	if c.Hardening != nil {
+		add(c.Hardening.validate())
+	}
+
+	for _, r := range c.FirewallRules {
+		if r.Port == 0 {
+			add(fmt.Errorf("firewall rule %q: port is not set", r.Name))
+		}
+		if p := strings.ToLower(r.Protocol); p != "" && p != "tcp" && p != "udp" {
+			add(fmt.Errorf("firewall rule %q: unknown protocol %q", r.Name, r.Protocol))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateAccount returns error if account is not LocalSystem, DOMAIN\user, .\user, NT SERVICE\name or user@domain.
+func validateAccount(account string) error {
+	if account == "" || strings.EqualFold(account, "LocalSystem") {
+		return nil
+	}
+
+	var domain, user string
+	switch {
+	case strings.Contains(account, `\`):
+		parts := strings.SplitN(account, `\`, 2)
+		domain, user = parts[0], parts[1]
+	case strings.Contains(account, "@"):
+		parts := strings.SplitN(account, "@", 2)
+		user, domain = parts[0], parts[1]
+	default:
+		return fmt.Errorf("account %q: expected DOMAIN\\user, .\\user, NT SERVICE\\name or user@domain", account)
+	}
+
+	if domain == "" || user == "" {
+		return fmt.Errorf("account %q: domain or user is empty", account)
+	}
+
+	if i := strings.IndexAny(user, `\/"[]:|<>+=;,?*@`); i >= 0 {
+		return fmt.Errorf("account %q: user contains forbidden character %q", account, user[i])
+	}
+	return nil
+}
+
+// builtinAccount reports whether account is built-in account of services which can always log on as service.
+func builtinAccount(account string) bool {
+	a := strings.ToLower(account)
+	switch a {
+	case "", "localsystem", `nt authority\system`, `nt authority\localservice`, `nt authority\local service`,
+		`nt authority\networkservice`, `nt authority\network service`:
+		return true
+	}
+	// virtual accounts and group managed service accounts
+	return strings.HasPrefix(a, `nt service\`) || strings.HasSuffix(a, "$")
+}
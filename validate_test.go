@@ -0,0 +1,52 @@
+package winsvc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	valid := []Config{
+		{},
+		{Name: "app", Account: `NT SERVICE\app`},
+		{Account: `.\user`, Password: "secret"},
+		{Account: `.\user`, PasswordStdin: true},
+		{Account: `DOMAIN\user`, StartType: StartAutomatic, DelayedAutoStart: true},
+		{Account: "user@example.com", Recovery: []RecoveryAction{{Type: RecoveryRunCommand}}, RecoveryCommand: "notify.exe"},
+		{Account: `DOMAIN\gmsa$`},
+		{TimeoutStop: 30, TimeoutShutdown: 5, LogLevel: "Warning"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("exp: valid %+v, got: %v", c, err)
+		}
+	}
+
+	c := Config{
+		Name:             "a:b",
+		StartType:        StartManual,
+		DelayedAutoStart: true,
+		Account:          `DOMAIN\`,
+		Recovery:         []RecoveryAction{{Type: RecoveryRestart, Delay: -1}},
+		FirewallRules:    []FirewallRule{{Name: "http", Protocol: "icmp"}},
+		TimeoutStop:      -1,
+		LogLevel:         "debug",
+	}
+	err := c.Validate()
+	errs, ok := err.(ConfigErrors)
+	if !ok || len(errs) != 8 {
+		t.Fatalf("exp: 8 violations, got: %v", err)
+	}
+
+	if !errors.Is(err, ErrInvalidConfig) || !errors.Is(err, ErrInvalidName) {
+		t.Errorf("exp: ErrInvalidConfig and ErrInvalidName, got: %v", err)
+	}
+}
+
+func TestValidateAccount(t *testing.T) {
+	for _, a := range []string{"user", `\user`, "@domain", `DOMAIN\us"er`} {
+		if err := validateAccount(a); err == nil {
+			t.Errorf("exp: error of %q", a)
+		}
+	}
+}
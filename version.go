@@ -0,0 +1,57 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// WithVersionInDescription is a option to append version and commit of the build to description of the service at install,
+// so installed build is seen in services.msc. Action version prints them.
+func WithVersionInDescription(version, commit string) option {
+	return func(m *manager) {
+		m.version = version
+		m.commit = commit
+		m.versionInDescription = true
+	}
+}
+
+// buildInfo returns version and commit of the build, version of the main module is used if version is not set.
+func (m *manager) buildInfo() string {
+	version := m.version
+	if version == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			version = info.Main.Version
+		}
+	}
+
+	if version == "" {
+		version = "unknown"
+	}
+
+	if m.commit == "" {
+		return "version " + version
+	}
+	return fmt.Sprintf("version %s, commit %s", version, m.commit)
+}
+
+// describeVersion returns description of the service with build info.
+func (m *manager) describeVersion(desc string) string {
+	if !m.versionInDescription {
+		return desc
+	}
+
+	if desc == "" {
+		return m.buildInfo()
+	}
+	return desc + " (" + m.buildInfo() + ")"
+}
+
+// printVersion prints build info of the program.
+func (m *manager) printVersion(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s (%s %s/%s)\n", m.buildInfo(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return err
+}
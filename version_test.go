@@ -0,0 +1,24 @@
+// +build windows
+
+package winsvc
+
+import "testing"
+
+func TestManager_DescribeVersion(t *testing.T) {
+	tests := []struct {
+		opts []option
+		desc string
+		exp  string
+	}{
+		{nil, "App", "App"},
+		{[]option{WithVersionInDescription("1.2.3", "abc1234")}, "App", "App (version 1.2.3, commit abc1234)"},
+		{[]option{WithVersionInDescription("1.2.3", "")}, "", "version 1.2.3"},
+	}
+
+	for _, tt := range tests {
+		m := newManager(nil, tt.opts...)
+		if got := m.describeVersion(tt.desc); got != tt.exp {
+			t.Errorf("exp: %s, got: %s", tt.exp, got)
+		}
+	}
+}
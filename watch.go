@@ -0,0 +1,167 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// debounceWatch is a time of waiting other changes of file before callback.
+	debounceWatch = time.Millisecond * 500
+
+	watchMask = windows.FILE_NOTIFY_CHANGE_FILE_NAME | windows.FILE_NOTIFY_CHANGE_LAST_WRITE |
+		windows.FILE_NOTIFY_CHANGE_SIZE | windows.FILE_NOTIFY_CHANGE_CREATION
+)
+
+// WatchConfig is a option to reload configuration of the program without restart.
+// reload is called when the file is changed (after a short pause of changes)
+// or the service gets ParamChange control (sc.exe control <name> paramchange).
+// Results of reload are written to event log.
+func WatchConfig(path string, reload func(ctx context.Context) error) option {
+	return func(m *manager) {
+		m.watchPath = path
+		m.reload = reload
+	}
+}
+
+// startWatch starts watching of configuration file if it is set.
+func (m *manager) startWatch() {
+	if m.reload == nil || m.watchPath == "" {
+		return
+	}
+
+	ctx := m.runContext()
+	go func() {
+		if err := watchFile(ctx, m.watchPath, debounce(debounceWatch, m.reloadConfig)); err != nil {
+			m.report(LevelError, codeWatchFailed, "watch config "+m.watchPath+": "+err.Error())
+		}
+	}()
+}
+
+// reloadConfig calls reload function, calls are serialized.
+func (m *manager) reloadConfig() {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	if err := m.reload(m.runContext()); err != nil {
+		m.report(LevelError, codeReloadFailed, "reload config: "+err.Error())
+		return
+	}
+	m.report(LevelInfo, codeReloaded, "config reloaded")
+}
+
+// debounce returns function which calls f after d since the last call.
+func debounce(d time.Duration, f func()) func() {
+	var (
+		mu sync.Mutex
+		t  *time.Timer
+	)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if t != nil {
+			t.Stop()
+		}
+		t = time.AfterFunc(d, f)
+	}
+}
+
+// watchFile calls changed when the file is changed, it is blocked until context is done.
+// Directory of the file is watched by ReadDirectoryChangesW, so the file can be created or replaced.
+func watchFile(ctx context.Context, path string, changed func()) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dir, base := filepath.Split(abs)
+
+	h, err := windows.CreateFile(windows.StringToUTF16Ptr(dir), windows.FILE_LIST_DIRECTORY,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil,
+		windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS|windows.FILE_FLAG_OVERLAPPED, 0)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+
+	ev, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(ev)
+
+	stop, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(stop)
+
+	exit := make(chan struct{})
+	defer close(exit)
+	go func() {
+		select {
+		case <-ctx.Done():
+			windows.SetEvent(stop)
+		case <-exit:
+		}
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		ov := &windows.Overlapped{HEvent: ev}
+		if err := windows.ResetEvent(ev); err != nil {
+			return err
+		}
+
+		if err := windows.ReadDirectoryChanges(h, &buf[0], uint32(len(buf)), false, watchMask, nil, ov, 0); err != nil {
+			return err
+		}
+
+		w, err := windows.WaitForMultipleObjects([]windows.Handle{ev, stop}, false, windows.INFINITE)
+		if err != nil {
+			return err
+		}
+
+		var n uint32
+		if w == windows.WAIT_OBJECT_0+1 {
+			windows.CancelIoEx(h, ov)
+			windows.GetOverlappedResult(h, ov, &n, true)
+			return nil
+		}
+
+		if err := windows.GetOverlappedResult(h, ov, &n, false); err != nil {
+			return err
+		}
+
+		// zero size means overflow of buffer, changes are lost
+		if n == 0 || notifyHasFile(buf[:n], base) {
+			changed()
+		}
+	}
+}
+
+// notifyHasFile reports whether buffer of FILE_NOTIFY_INFORMATION entries contains the file.
+func notifyHasFile(buf []byte, name string) bool {
+	for off := uint32(0); off < uint32(len(buf)); {
+		fni := (*windows.FileNotifyInformation)(unsafe.Pointer(&buf[off]))
+		size := fni.FileNameLength / 2
+		fileName := (*[1 << 15]uint16)(unsafe.Pointer(&fni.FileName))[:size:size]
+		if strings.EqualFold(windows.UTF16ToString(fileName), name) {
+			return true
+		}
+
+		if fni.NextEntryOffset == 0 {
+			break
+		}
+		off += fni.NextEntryOffset
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	var calls int32
+	f := debounce(time.Millisecond*50, func() { atomic.AddInt32(&calls, 1) })
+	for i := 0; i < 5; i++ {
+		f()
+	}
+
+	time.Sleep(time.Millisecond * 200)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("exp: 1, got: %d", got)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "winsvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchFile(ctx, path, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	time.Sleep(time.Millisecond * 100)
+	if err := ioutil.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second * 5):
+		t.Error("exp: file is changed")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("exp: nil, got: %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("exp: watch is stopped")
+	}
+}
+
+func TestRestartOnChange_Interactive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "winsvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.exe")
+	m := newManager(func(ctx context.Context) {}, RestartOnChange(path))
+	m.interactive = true
+	m.startRestartWatch()
+	defer close(m.done)
+
+	time.Sleep(time.Millisecond * 100)
+	if err := ioutil.WriteFile(path, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case reason := <-m.restartReq:
+		if exp := "file " + path + " is changed"; reason != exp {
+			t.Errorf("exp: %s, got: %s", exp, reason)
+		}
+	case <-time.After(time.Second * 3):
+		t.Error("exp: restart of run function")
+	}
+}
@@ -0,0 +1,205 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// watchdogInterval is a period of checking usage of resources by the process.
+const watchdogInterval = time.Second * 30
+
+// Restarts by memory limit are delayed from 5 minutes to an hour, the service is failed after 3 restarts in a row,
+// so recovery actions of the service manager are applied.
+var memoryRestarts = backoff{min: time.Minute * 5, max: time.Hour, attempts: 3}
+
+var procGetProcessMemoryInfo = modkernel32.NewProc("K32GetProcessMemoryInfo")
+
+// processMemoryCounters is PROCESS_MEMORY_COUNTERS structure.
+type processMemoryCounters struct {
+	cb                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// WithMemoryLimit is a option to restart run function gracefully when working set of the process exceeds limit in bytes,
+// it is a mitigation of slow leaks. Warning is written to event log before restart. Restarts are delayed by backoff,
+// the service is stopped with failure if working set exceeds the limit after several restarts in a row.
+func WithMemoryLimit(limit uint64) option {
+	return func(m *manager) {
+		m.memoryLimit = limit
+	}
+}
+
+// WithCPULimit is a option to alert when usage of CPU by the process is above percent (of all CPUs) during d,
+// so runaway loops are surfaced. Warning is written to event log and alert is called if it is not nil.
+func WithCPULimit(percent float64, d time.Duration, alert func(percent float64)) option {
+	return func(m *manager) {
+		m.cpuLimit = cpuLimit{percent: percent, duration: d, alert: alert}
+	}
+}
+
+// cpuLimit is a limit of usage of CPU.
+type cpuLimit struct {
+	percent  float64
+	duration time.Duration
+	alert    func(percent float64)
+}
+
+// sustained detects that condition holds during duration, it reports once until condition is broken.
+type sustained struct {
+	since    time.Time
+	reported bool
+}
+
+// update updates the condition at now and reports whether it holds during d the first time.
+func (s *sustained) update(now time.Time, over bool, d time.Duration) bool {
+	if !over {
+		s.since, s.reported = time.Time{}, false
+		return false
+	}
+
+	if s.since.IsZero() {
+		s.since = now
+	}
+
+	if s.reported || now.Sub(s.since) < d {
+		return false
+	}
+	s.reported = true
+	return true
+}
+
+// startWatchdogs starts watchdogs of the process which are set by options, they are stopped when the service is stopped.
+func (m *manager) startWatchdogs() {
+	if m.memoryLimit > 0 {
+		go m.watchMemory()
+	}
+
+	if m.cpuLimit.percent > 0 {
+		go m.watchCPU()
+	}
+}
+
+// watchMemory requests restart of run function when working set exceeds the limit.
+func (m *manager) watchMemory() {
+	t := time.NewTicker(watchdogInterval)
+	defer t.Stop()
+
+	l := limiter{backoff: memoryRestarts}
+	for {
+		var now time.Time
+		select {
+		case <-m.done:
+			return
+		case now = <-t.C:
+		}
+
+		ws, err := workingSet()
+		if err != nil || ws <= m.memoryLimit {
+			continue
+		}
+
+		msg := fmt.Sprintf("working set %d MB exceeds limit %d MB", ws>>20, m.memoryLimit>>20)
+		ok, exhausted := l.allow(now)
+		if exhausted {
+			m.report(LevelError, codeMemoryLimit, fmt.Sprintf("%s after %d restarts in a row, the service is stopped", msg, l.attempts))
+			m.stopWithFailure()
+			return
+		}
+
+		if !ok {
+			continue
+		}
+		m.report(LevelWarning, codeMemoryLimit, msg)
+		m.requestRestart(msg)
+		debug.FreeOSMemory()
+	}
+}
+
+// stopWithFailure stops the service with exit code 1 if it is not set, so the stop is a failure for recovery actions.
+func (m *manager) stopWithFailure() {
+	atomic.CompareAndSwapUint32(&m.exitCode, 0, 1)
+	m.stopOnce.Do(func() { close(m.stopReq) })
+}
+
+// watchCPU reports usage of CPU which is above the limit during the duration.
+func (m *manager) watchCPU() {
+	t := time.NewTicker(watchdogInterval)
+	defer t.Stop()
+
+	var (
+		s        sustained
+		prev, _  = cpuTime()
+		prevTime = time.Now()
+	)
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-t.C:
+			cur, err := cpuTime()
+			if err != nil {
+				continue
+			}
+
+			percent := float64(cur-prev) / float64(now.Sub(prevTime)*time.Duration(runtime.NumCPU())) * 100
+			prev, prevTime = cur, now
+
+			if !s.update(now, percent > m.cpuLimit.percent, m.cpuLimit.duration) {
+				continue
+			}
+
+			m.report(LevelWarning, codeCPULimit, fmt.Sprintf("usage of CPU %.0f%% is above limit %.0f%% during %s",
+				percent, m.cpuLimit.percent, m.cpuLimit.duration))
+			if m.cpuLimit.alert != nil {
+				m.cpuLimit.alert(percent)
+			}
+		}
+	}
+}
+
+// requestRestart requests restart of run function, it is skipped if the service is stopped.
+func (m *manager) requestRestart(reason string) {
+	select {
+	case m.restartReq <- reason:
+	case <-m.done:
+	}
+}
+
+// cpuTime returns kernel and user time of the current process.
+func cpuTime() (time.Duration, error) {
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(windows.CurrentProcess(), &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+
+	// Filetime is in 100-nanosecond intervals
+	ticks := (int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)) + (int64(user.HighDateTime)<<32 | int64(user.LowDateTime))
+	return time.Duration(ticks * 100), nil
+}
+
+// workingSet returns working set of the current process in bytes.
+func workingSet() (uint64, error) {
+	var c processMemoryCounters
+	c.cb = uint32(unsafe.Sizeof(c))
+	r, _, err := procGetProcessMemoryInfo.Call(uintptr(windows.CurrentProcess()), uintptr(unsafe.Pointer(&c)), uintptr(c.cb))
+	if r == 0 {
+		return 0, err
+	}
+	return uint64(c.WorkingSetSize), nil
+}
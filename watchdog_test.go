@@ -0,0 +1,47 @@
+// +build windows
+
+package winsvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkingSet(t *testing.T) {
+	ws, err := workingSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ws == 0 {
+		t.Errorf("exp: working set of the process")
+	}
+}
+
+func TestSustained(t *testing.T) {
+	var (
+		s     sustained
+		begin = time.Now()
+		d     = time.Minute
+	)
+
+	steps := []struct {
+		after time.Duration
+		over  bool
+		exp   bool
+	}{
+		{0, true, false},
+		{time.Second * 30, true, false},
+		{time.Minute, true, true},
+		{time.Minute * 2, true, false}, // reported once
+		{time.Minute * 3, false, false},
+		{time.Minute * 4, true, false},
+		{time.Minute * 5, true, true},
+	}
+
+	for i, step := range steps {
+		if got := s.update(begin.Add(step.after), step.over, d); got != step.exp {
+			t.Errorf("step %d exp: %t, got: %t", i, step.exp, got)
+		}
+	}
+}
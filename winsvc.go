@@ -6,20 +6,25 @@ package winsvc
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 )
 
 type (
 	option func(*manager)
 
-	// runFunc is the function that can runOnce as windows service.
+	// runFunc is the function that can run as windows service.
 	//
 	//   1. OS service manager executes user program.
 	//   2. User program sees it is executed from a service manager (when Interactive() is false).
@@ -33,31 +38,77 @@ type (
 	runFunc func(ctx context.Context)
 )
 
-var (
-	runOnce     sync.Once
-	interactive = false
+// Interactive returns false if running under the OS service manager and true otherwise.
+// It returns true if the detection failed, because program can not run as service anyway.
+func Interactive() bool {
+	isService, err := svc.IsWindowsService()
+	return err != nil || !isService
+}
+
+// chdirMode is a choice of working directory of the service.
+type chdirMode int
+
+// Working directories of the service.
+const (
+	chdirExecutable  chdirMode = iota // directory of the executable
+	chdirNone                         // working directory is kept
+	chdirDir                          // directory of ChdirTo
+	chdirProgramData                  // directory of the service in ProgramData
 )
 
-func init() {
-	ex, errEx := os.Executable()
-	if errEx != nil {
-		panic(errEx)
+// chdir changes working directory to directory of the executable for easy using relative path,
+// options DisableChdir, ChdirTo and ChdirToProgramData change the directory.
+func (m *manager) chdir() error {
+	switch m.chdirMode {
+	case chdirNone:
+		return nil
+	case chdirDir:
+		return os.Chdir(m.chdirDir)
 	}
 
-	if err := os.Chdir(filepath.Dir(ex)); err != nil {
-		panic(err)
+	ex, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(filepath.Dir(ex)); err != nil || m.chdirMode != chdirProgramData {
+		return err
 	}
 
-	var errIn error
-	interactive, errIn = svc.IsAnInteractiveSession()
-	if errIn != nil {
-		panic(errIn)
+	// relative path of configuration file is resolved in directory of the executable
+	c, err := m.effectiveConfig()
+	if err != nil {
+		return err
 	}
+
+	dir := ProgramDataDir(c.Name)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil // it is created by install
+	}
+	return os.Chdir(dir)
 }
 
-// Interactive returns false if running under the OS service manager and true otherwise.
-func Interactive() bool {
-	return interactive
+// DisableChdir is a option to keep working directory, by default Run changes it to directory of the executable.
+func DisableChdir() option {
+	return func(m *manager) {
+		m.chdirMode = chdirNone
+	}
+}
+
+// ChdirTo is a option to change working directory to dir instead of directory of the executable.
+func ChdirTo(dir string) option {
+	return func(m *manager) {
+		m.chdirMode = chdirDir
+		m.chdirDir = dir
+	}
+}
+
+// ChdirToProgramData is a option to change working directory to directory of the service in ProgramData (see ProgramDataDir),
+// so the service does not write next to the binary under Program Files. Directory of the executable is kept
+// until the directory is created at install (see Config.ProgramData).
+func ChdirToProgramData() option {
+	return func(m *manager) {
+		m.chdirMode = chdirProgramData
+	}
 }
 
 // TimeoutStop is a option to specify timeout of stopping service.
@@ -69,6 +120,22 @@ func TimeoutStop(t time.Duration) option {
 	}
 }
 
+// TimeoutShutdown is a option to specify timeout of stopping service at shutdown of the system,
+// it is usually shorter than TimeoutStop. If is not set option, value of TimeoutStop is used.
+func TimeoutShutdown(t time.Duration) option {
+	return func(m *manager) {
+		m.timeoutShutdown = t
+	}
+}
+
+// TimeoutPreShutdown is a option to accept preshutdown control of the service manager and specify timeout of stopping by it.
+// The service is stopped before shutdown of the system and it gets more time than at shutdown.
+func TimeoutPreShutdown(t time.Duration) option {
+	return func(m *manager) {
+		m.timeoutPreShutdown = t
+	}
+}
+
 // DisablePanic is a option to disabling panic when exit from run function.
 func DisablePanic() option {
 	return func(m *manager) {
@@ -76,6 +143,74 @@ func DisablePanic() option {
 	}
 }
 
+// WithScheduledRestart is a option to restart run function periodically in the maintenance window of cron expression
+// "minute hour day-of-month month day-of-week", the time of restart is random inside the window,
+// for example "0-29 3 * * 0" restarts at 3:00-3:30 every Sunday. It panics if expression is invalid.
+func WithScheduledRestart(cron string) option {
+	s, err := parseSchedule(cron)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(m *manager) {
+		m.restartSchedule = s
+	}
+}
+
+// WithRestartBackoff is a option to restart run function which exits before stop instead of panic.
+// Delay of restart is doubled from min to max, restarts are stopped after attempts in a row (0 is unlimited)
+// and the service fails like without the option, so the service manager applies recovery actions.
+// Run function which has worked longer than max resets count of attempts.
+func WithRestartBackoff(min, max time.Duration, attempts int) option {
+	return func(m *manager) {
+		m.backoff = &backoff{min: min, max: max, attempts: attempts}
+	}
+}
+
+// WithForceInteractive is a option to run in console mode even if the program is detected as service,
+// it is useful on unusual hosts (containers, scheduled tasks). Action "run -console" does the same.
+func WithForceInteractive() option {
+	return func(m *manager) {
+		m.interactive = true
+	}
+}
+
+// WithCommands is a option to limit actions of the command line to cmds, other arguments are left to the program,
+// so the program can have own subcommands with the same names (for example start or config).
+// Without cmds the command line is not handled, default is all actions.
+func WithCommands(cmds ...Command) option {
+	return func(m *manager) {
+		m.commands = make(map[Command]bool, len(cmds))
+		for _, c := range cmds {
+			m.commands[c] = true
+		}
+	}
+}
+
+// WithSignals is a option to specify signals which stop the service in interactive mode,
+// default signals are os.Interrupt (Ctrl+C, Ctrl+Break) and syscall.SIGTERM.
+func WithSignals(sig ...os.Signal) option {
+	return func(m *manager) {
+		m.signals = sig
+	}
+}
+
+// WithAcceptedControls is a option to set controls which the service accepts, default is svc.AcceptStop | svc.AcceptShutdown.
+// Controls of other options (WatchConfig, OnNetBind, TimeoutPreShutdown) are added to them.
+func WithAcceptedControls(a svc.Accepted) option {
+	return func(m *manager) {
+		m.accepted = a
+	}
+}
+
+// IgnoreShutdown is a option to not handle shutdown of the system, the process is terminated by the system then.
+// It is useful when work of stop is pointless at shutdown, stop by the service manager is graceful as before.
+func IgnoreShutdown() option {
+	return func(m *manager) {
+		m.ignoreShutdown = true
+	}
+}
+
 // signalNotify is a option to mock.
 func signalNotify(f func(c chan<- os.Signal, sig ...os.Signal)) option {
 	return func(m *manager) {
@@ -83,18 +218,37 @@ func signalNotify(f func(c chan<- os.Signal, sig ...os.Signal)) option {
 	}
 }
 
-// start starts a service. Separated from sync.One for tests.
-func start(r runFunc, opts ...option) {
+// newManager returns manager of the service with options.
+func newManager(r runFunc, opts ...option) *manager {
 	svcMan := &manager{
 		svcHandler:   r,
 		timeout:      time.Second * 20,
 		signalNotify: signal.Notify,
+		signals:      []os.Signal{os.Interrupt, syscall.SIGTERM},
+		stopReq:      make(chan struct{}),
+		done:         make(chan struct{}),
+		restartReq:   make(chan string),
+		interactive:  Interactive(),
+		clock:        realClock{},
+		accepted:     svc.AcceptStop | svc.AcceptShutdown,
 	}
 
 	for _, op := range opts {
 		op(svcMan)
 	}
-	svcMan.run()
+	return svcMan
+}
+
+// withClock is a option to set source of time of timeouts and restarts, it is used in tests.
+func withClock(c clock) option {
+	return func(m *manager) {
+		m.clock = c
+	}
+}
+
+// start starts a service.
+func start(r runFunc, opts ...option) {
+	newManager(r, opts...).run()
 }
 
 // Run initializes new windows service and runs command action.
@@ -102,92 +256,596 @@ func start(r runFunc, opts ...option) {
 // runFunc function always has blocked and exit from it, means that service will be stopped correctly if is context was canceled.
 // runFunc should not call os.Exit directly in the function, it is not correctly service stop.
 // Context canceled it is mean that signal of stop got and need to stop run function.
+// Run should be called once, OS service manager runs only one service in the process.
 func Run(r runFunc, opts ...option) {
-	runOnce.Do(func() { start(r, opts...) })
+	start(r, opts...)
+}
+
+// RunE is like Run, but it returns error instead of panic and exit of the process, so main can exit with code (see ExitCode).
+// Error reflects how run function has ended: nil after stop, ErrRunExited, panic of run function,
+// *ExitError with code of Handle.SetExitCode in interactive mode or error of command action.
+//
+//	os.Exit(winsvc.ExitCode(winsvc.RunE(run)))
+func RunE(r runFunc, opts ...option) error {
+	m := newManager(r, opts...)
+	m.returnErr = true
+	m.run()
+	return m.err
 }
 
 type manager struct {
-	svcHandler   runFunc
-	ctxSvc       context.Context
-	cancelSvc    context.CancelFunc
-	svc.Handler  // svcHandler.Handler is controlled OS service manager
-	timeout      time.Duration
-	disablePanic bool
-	signalNotify func(c chan<- os.Signal, sig ...os.Signal) // for mock and tests.
+	svcHandler         runFunc
+	ctxMu              sync.Mutex // guards ctxSvc and cancelSvc which are replaced by restarts
+	ctxSvc             context.Context
+	cancelSvc          func(deadline time.Time) // cancels context of run function with deadline of graceful stop
+	svc.Handler                                 // svcHandler.Handler is controlled OS service manager
+	timeout            time.Duration
+	timeoutShutdown    time.Duration
+	timeoutPreShutdown time.Duration
+	killTimeout        time.Duration // WaitToKillServiceTimeout of the system, 0 is unknown
+	accepted           svc.Accepted  // controls which are accepted besides controls of options
+	ignoreShutdown     bool
+	commands           map[Command]bool // actions of the command line, nil is all actions
+	chdirMode          chdirMode        // working directory which is set by Run
+	chdirDir           string           // directory of ChdirTo
+	disablePanic       bool
+	config             Config // config of install
+	elog               *EventLog
+	logLevel           Level    // minimal level of reported entries, 0 reports all
+	eventMap           EventMap // identifiers of events and categories of reported entries
+	configFile         string
+	instance           string                                     // name of the service which overrides the configuration
+	signalNotify       func(c chan<- os.Signal, sig ...os.Signal) // for mock and tests.
+	clock              clock                                      // for mock and tests.
+	signals            []os.Signal                                // signals of stop in interactive mode
+	interactive        bool
+	endSessionWindow   bool // hidden window stops the service on end of session in interactive mode
+	watchPath          string
+	restartPaths       []string // files whose change restarts the service (see RestartOnChange)
+	name               string   // name of the service in service mode
+	version            string
+	commit             string
+	status             extStatus
+	reload             func(ctx context.Context) error
+	reloadMu           sync.Mutex
+
+	restartSchedule *schedule
+	restartReq      chan string // reason of restart of run function which is requested by watchdogs
+	memoryLimit     uint64
+	cpuLimit        cpuLimit
+	heartbeat       time.Duration
+	heartbeatFile   string
+	heartbeatFailed bool
+	crashLoop       crashLoop
+	backoff         *backoff    // restarts of run function which exits before stop, it is nil if they are not set
+	runPanic        interface{} // value of panic of run function, it is set before run function is finished
+	returnErr       bool        // error is returned by RunE instead of panic
+	err             error       // error of run which is returned by RunE
+	startArgs       []string    // arguments of start which are passed to context of run function
+	runStart        time.Time
+	networkWait     time.Duration // run function waits the network, 0 is disabled
+	networkHosts    []string      // names which must be resolved before run function
+	installHooks    InstallHooks
+
+	versionInDescription bool
+
+	state         uint32        // svc.State, it is accessed atomically
+	exitCode      uint32        // service-specific exit code, it is accessed atomically
+	stopReq       chan struct{} // closed by StopAsync
+	stopOnce      sync.Once
+	done          chan struct{} // closed when run returns
+	onStart       []func()
+	onStop        []func()
+	onDrain       []func(ctx context.Context, report func(done, total int))
+	onNetBind     []func(change NetBindChange)
+	onTrigger     []func(e TriggerEvent)
+	controlMW     []func(next ControlHandler) ControlHandler
+	rawControls   chan svc.ChangeRequest // controls are sent to the application if it is not nil
+	services      map[string]runFunc     // run functions by lower name of the service (see RegisterService)
+	onTimeChange  []func(old, new time.Time)
+	onSession     []func(c SessionChange)
+	onInterrogate []func()
 }
 
 // run starts service.
 func (m *manager) run() {
-	m.ctxSvc, m.cancelSvc = context.WithCancel(context.Background())
+	defer close(m.done)
+	defer m.setState(svc.Stopped)
+
+	if f, ok := parseRunFlags(os.Args[1:]); ok && m.allowCommand(CmdRun) {
+		m.interactive = m.interactive || f.console
+		if f.name != "" {
+			m.instance = f.name
+		}
+	}
+
+	if err := m.chdir(); err != nil {
+		m.fail(err)
+		return
+	}
 
-	if !interactive {
-		errRun := svc.Run("", m)
-		if errRun != nil {
-			panic(errRun)
+	if m.interactive {
+		if ok, err := m.runCommand(); ok {
+			if err != nil {
+				m.fail(err)
+			}
+			return
+		}
+	}
+
+	m.newRun()
+
+	if !m.interactive {
+		if c, err := m.effectiveConfig(); err == nil {
+			m.name = c.Name
+			m.applyConfig(c)
+			m.addHookCommands(c)
+			if l, err := OpenEventLog(c.Name); err == nil {
+				l.SetEventMap(m.eventMap)
+				m.elog = l
+				defer l.Close()
+			}
 		}
+		m.overrideTimeouts(m.name)
+		m.checkKillTimeout()
+
+		if err := svc.Run(m.name, m); err != nil {
+			m.fail(wrapError("run", "", err))
+		}
+		return
+	}
+	if !m.dispatch(m.instance) {
+		m.fail(wrapError("run", m.instance, windows.ERROR_SERVICE_NOT_IN_EXE))
 		return
 	}
+	if c, err := m.effectiveConfig(); err == nil {
+		m.applyConfig(c)
+	}
+	m.overrideTimeouts("")
+	m.setState(svc.StartPending)
 	finishRun := m.runFuncWithNotify()
+	m.setState(svc.Running)
+	m.callHooks(m.onStart)
+	m.startWatch()
+	m.startRestartWatch()
+	m.startWatchdogs()
 
 	// waiting interrupt signal in interactive mode or cancel context
 	sig := make(chan os.Signal, 1)
-	m.signalNotify(sig, os.Interrupt, syscall.SIGTERM)
-	select {
-	case <-sig:
-		m.cancelSvc()
-	case <-finishRun:
-		if !m.disablePanic {
-			panic("exit from run function")
+	m.signalNotify(sig, m.signals...)
+	if unregister, err := m.handleConsoleClose(); err == nil {
+		defer unregister()
+	}
+	if m.endSessionWindow {
+		if closeWindow, err := m.handleEndSession(); err == nil {
+			defer closeWindow()
 		}
-		return
 	}
+	var restart, retry deadline // scheduled restart and restart of run function after its exit
+	var restarting bool         // run function is canceled to restart, the next run is started when it returns
+	defer restart.stop()
+	defer retry.stop()
+	m.scheduleRestart(&restart)
+	beat, stopBeat := m.startHeartbeat()
+	defer stopBeat()
+loop:
+	for {
+		select {
+		case <-sig:
+			m.stopping(nil, m.timeout)
+			break loop
+		case <-m.stopReq:
+			m.stopping(nil, m.timeout)
+			break loop
+		case <-restart.C():
+			restart.fired()
+			retry.stop()
+			finishRun = m.restartRun(finishRun, &restarting, "scheduled restart")
+			m.scheduleRestart(&restart)
+		case reason := <-m.restartReq:
+			retry.stop()
+			finishRun = m.restartRun(finishRun, &restarting, reason)
+		case <-retry.C():
+			retry.fired()
+			finishRun = m.restartRun(nil, &restarting, "exited run function")
+		case now := <-beat:
+			m.writeHeartbeat(now)
+		case <-finishRun:
+			if restarting && m.runPanic == nil {
+				restarting = false
+				finishRun = m.nextRun()
+				continue
+			}
 
-	select {
-	case <-finishRun:
-	case <-time.After(m.timeout):
+			if m.runPanic != nil {
+				m.err = fmt.Errorf("panic of run function: %v", m.runPanic)
+				return
+			}
+
+			if d, ok := m.runExited(); ok {
+				retry.reset(m.clock, d)
+				finishRun = nil
+				continue
+			}
+
+			if !m.disablePanic {
+				m.fail(ErrRunExited)
+			}
+			return
+		}
+	}
+	m.waitRun(finishRun, m.timeout)
+	if code := atomic.LoadUint32(&m.exitCode); code != 0 {
+		m.err = &ExitError{Code: code}
 	}
 }
 
+// fail stops run with error, it is returned by RunE or it is a value of panic.
+func (m *manager) fail(err error) {
+	if !m.returnErr {
+		panic(err)
+	}
+	m.err = err
+}
+
 // runFuncWithNotify returns context which will done when run function is stopped.
 func (m *manager) runFuncWithNotify() <-chan struct{} {
 	finishRun, cancelRun := context.WithCancel(context.Background())
+	m.runStart = m.clock.Now()
+	ctx := m.runContext()
 	go func() {
 		defer cancelRun()
-		m.svcHandler(m.ctxSvc)
+		if !m.interactive || m.returnErr {
+			defer m.recoverRun()
+		}
+		ctx = context.WithValue(ctx, managerKey{}, m)
+		if m.startArgs != nil {
+			ctx = context.WithValue(ctx, startArgsKey{}, m.startArgs)
+		}
+		m.waitNetwork(ctx)
+		m.svcHandler(ctx)
 	}()
 	return finishRun.Done()
 }
 
+// managerKey is a key of manager in context of run function.
+type managerKey struct{}
+
+// reportContext writes entry to event log of the service which runs run function of context,
+// it is written by log if context is not of run function.
+func reportContext(ctx context.Context, level Level, code uint16, msg string) {
+	if m, ok := ctx.Value(managerKey{}).(*manager); ok {
+		m.report(level, code, msg)
+		return
+	}
+	log.Printf("[%s] %s", levelString(level), msg)
+}
+
+// recoverRun recovers panic of run function in service mode and writes it with stack to event log,
+// the service is stopped with failure then, so recovery actions of the service manager are fired.
+func (m *manager) recoverRun() {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	m.runPanic = v
+	m.report(LevelError, codeRunPanic, fmt.Sprintf("panic of run function: %v\n%s", v, debug.Stack()))
+}
+
 // Execute manages status of the service.
 func (m *manager) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
-	const cmdAccepted = svc.AcceptStop | svc.AcceptShutdown
-	changes <- svc.Status{State: svc.StartPending}
+	if len(args) > 0 && !m.dispatch(args[0]) {
+		m.report(LevelError, codeRunExited, "no run function of service "+args[0])
+		return false, uint32(windows.ERROR_SERVICE_NOT_IN_EXE)
+	}
+
+	m.setStartArgs(args)
+
+	status := newStatusReporter(changes)
+	requests, release := m.requests(args, r, status)
+	defer release()
+	status.set(svc.Status{State: svc.StartPending})
+	m.setState(svc.StartPending)
 	finishRun := m.runFuncWithNotify()
 
-	changes <- svc.Status{State: svc.Running, Accepts: cmdAccepted}
+	status.set(svc.Status{State: svc.Running, Accepts: m.acceptedControls()})
+	m.setState(svc.Running)
+	m.report(LevelInfo, codeStarted, "service started")
+	m.checkCrashLoop()
+	if m.name != "" {
+		if err := m.status.publish(m.name, m.version); err != nil {
+			m.report(LevelWarning, codeStatusFailed, "publish status: "+err.Error())
+		}
+		defer m.status.unpublish()
+	}
+	m.callHooks(m.onStart)
+	m.startWatch()
+	m.startRestartWatch()
+	m.startWatchdogs()
+	var restart, retry deadline // scheduled restart and restart of run function after its exit
+	var restarting bool         // run function is canceled to restart, the next run is started when it returns
+	defer restart.stop()
+	defer retry.stop()
+	m.scheduleRestart(&restart)
+	beat, stopBeat := m.startHeartbeat()
+	defer stopBeat()
+
+	var (
+		stopped bool
+		data    eventData // copied data of the control which is handled
+	)
+	if m.rawControls != nil {
+		defer close(m.rawControls)
+	}
+	handle := m.controlHandler(func(c svc.ChangeRequest) {
+		if stopped || m.sendRaw(c) {
+			return
+		}
+
+		switch c.Cmd {
+		case svc.Interrogate:
+			m.callHooks(m.onInterrogate)
+			status.report()
+		case svc.ParamChange:
+			go m.reloadConfig()
+		case svc.NetBindAdd, svc.NetBindRemove, svc.NetBindEnable, svc.NetBindDisable:
+			m.netBind(NetBindChange(c.Cmd))
+		case cmdTriggerEvent:
+			m.triggerEvent(TriggerEvent{EventType: c.EventType})
+		case cmdTimeChange:
+			m.timeChange(data)
+		case svc.SessionChange:
+			m.sessionChange(SessionEvent(c.EventType), data)
+		case svc.Stop, svc.Shutdown, svc.PreShutdown:
+			m.stopService(requests, status, finishRun, m.controlTimeout(c.Cmd))
+			stopped = true
+		}
+	})
 loop:
 	for {
 		select {
+		case <-restart.C():
+			restart.fired()
+			retry.stop()
+			finishRun = m.restartRun(finishRun, &restarting, "scheduled restart")
+			m.scheduleRestart(&restart)
+		case reason := <-m.restartReq:
+			retry.stop()
+			finishRun = m.restartRun(finishRun, &restarting, reason)
+		case <-retry.C():
+			retry.fired()
+			finishRun = m.restartRun(nil, &restarting, "exited run function")
+		case now := <-beat:
+			m.writeHeartbeat(now)
+		case <-m.stopReq:
+			m.stopService(requests, status, finishRun, m.timeout)
+			break loop
 		case <-finishRun:
+			if restarting && m.runPanic == nil {
+				restarting = false
+				finishRun = m.nextRun()
+				continue
+			}
+
+			if m.runPanic != nil {
+				status.set(svc.Status{State: svc.StopPending})
+				m.setState(svc.StopPending)
+				m.cancelRun(m.clock.Now())
+				m.err = fmt.Errorf("panic of run function: %v", m.runPanic)
+				return false, uint32(windows.ERROR_EXCEPTION_IN_SERVICE)
+			}
+
+			if d, ok := m.runExited(); ok {
+				retry.reset(m.clock, d)
+				finishRun = nil
+				continue
+			}
+
+			m.report(LevelError, codeRunExited, ErrRunExited.Error())
 			if !m.disablePanic {
-				panic("exit from run function")
+				m.fail(ErrRunExited)
+			}
+			if code := atomic.LoadUint32(&m.exitCode); code != 0 {
+				return true, code
 			}
 			return false, 1
-		case c := <-r:
-			switch c.Cmd {
-			case svc.Interrogate:
-				changes <- c.CurrentStatus
-			case svc.Stop, svc.Shutdown:
-				changes <- svc.Status{State: svc.StopPending}
-				m.cancelSvc() // cancel context svcHandler
-
-				select {
-				case <-finishRun:
-				case <-time.After(m.timeout):
-				}
+		case req := <-requests:
+			data = req.data
+			handle(req.ChangeRequest)
+			if stopped {
 				break loop
 			}
 		}
 	}
+	m.report(LevelInfo, codeStopped, "service stopped")
+	if code := atomic.LoadUint32(&m.exitCode); code != 0 {
+		return true, code
+	}
 	return false, 0
 }
+
+// acceptedControls returns controls which the service accepts.
+func (m *manager) acceptedControls() svc.Accepted {
+	a := m.accepted
+	if m.reload != nil {
+		a |= svc.AcceptParamChange
+	}
+	if len(m.onNetBind) > 0 {
+		a |= svc.AcceptNetBindChange
+	}
+	if len(m.onTrigger) > 0 {
+		a |= acceptTriggerEvent
+	}
+	if len(m.onTimeChange) > 0 {
+		a |= acceptTimeChange
+	}
+	if len(m.onSession) > 0 {
+		a |= svc.AcceptSessionChange
+	}
+	if m.timeoutPreShutdown > 0 {
+		a |= svc.AcceptPreShutdown
+	}
+	if m.ignoreShutdown {
+		a &^= svc.AcceptShutdown | svc.AcceptPreShutdown
+	}
+	return a
+}
+
+// scheduleRestart sets deadline of the next scheduled restart, it is not set if restart is not scheduled.
+func (m *manager) scheduleRestart(dl *deadline) {
+	if m.restartSchedule == nil {
+		return
+	}
+
+	now := m.clock.Now()
+	if at := m.restartSchedule.nextRestart(now); !at.IsZero() {
+		dl.reset(m.clock, at.Sub(now))
+	}
+}
+
+// runExited returns delay of restart of run function which has exited before stop,
+// it returns false if restarts are not set or they are exhausted.
+func (m *manager) runExited() (time.Duration, bool) {
+	if m.backoff == nil {
+		return 0, false
+	}
+
+	// run function which has worked longer than the cap of delay is not a crash loop
+	if m.clock.Now().Sub(m.runStart) >= m.backoff.max {
+		m.backoff.reset()
+	}
+
+	d, ok := m.backoff.next()
+	if !ok {
+		m.report(LevelError, codeCrashLoop, fmt.Sprintf("run function exited %d times in a row, restarts are stopped", m.backoff.attempts))
+		return 0, false
+	}
+
+	m.report(LevelWarning, codeRunExited, fmt.Sprintf("%v, restart in %s", ErrRunExited, d))
+	return d, true
+}
+
+// waitRun waits until run function returns, but no longer than timeout.
+func (m *manager) waitRun(finishRun <-chan struct{}, timeout time.Duration) {
+	if finishRun == nil {
+		return
+	}
+
+	t := m.clock.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-finishRun:
+	case <-t.C():
+	}
+}
+
+// controlTimeout returns timeout of stop by the control of the service manager.
+func (m *manager) controlTimeout(c svc.Cmd) time.Duration {
+	switch {
+	case c == svc.Shutdown && m.killTimeout > 0 && m.shutdownTimeout() > m.killTimeout:
+		return m.killTimeout
+	case c == svc.Shutdown && m.timeoutShutdown > 0:
+		return m.timeoutShutdown
+	case c == svc.PreShutdown && m.timeoutPreShutdown > 0:
+		return m.timeoutPreShutdown
+	}
+	return m.timeout
+}
+
+// waitHint returns hint of time of pending operation for the service manager.
+func waitHint(d time.Duration) uint32 {
+	return uint32(d / time.Millisecond)
+}
+
+// restartRun cancels context of run function to restart it, the next run is started by nextRun after the run function returns,
+// so runs do not overlap and controls are handled meanwhile. Run function which has exited (finishRun is nil) is started at once.
+func (m *manager) restartRun(finishRun <-chan struct{}, restarting *bool, reason string) <-chan struct{} {
+	if finishRun == nil {
+		m.report(LevelInfo, codeRestarted, "restart run function: "+reason)
+		return m.nextRun()
+	}
+
+	if !*restarting {
+		*restarting = true
+		m.report(LevelInfo, codeRestarted, "restart run function: "+reason)
+		m.cancelRun(m.clock.Now().Add(m.timeout))
+	}
+	return finishRun
+}
+
+// nextRun runs run function again with new context.
+func (m *manager) nextRun() <-chan struct{} {
+	m.newRun()
+	finishRun := m.runFuncWithNotify()
+	m.startWatch() // watching is stopped with context of the previous run
+	return finishRun
+}
+
+// newRun sets new context of run function.
+func (m *manager) newRun() {
+	m.ctxMu.Lock()
+	defer m.ctxMu.Unlock()
+	m.ctxSvc, m.cancelSvc = newRunContext()
+}
+
+// runContext returns context of the current run function.
+func (m *manager) runContext() context.Context {
+	m.ctxMu.Lock()
+	defer m.ctxMu.Unlock()
+	return m.ctxSvc
+}
+
+// cancelRun cancels context of the current run function with deadline of graceful stop.
+func (m *manager) cancelRun(deadline time.Time) {
+	m.ctxMu.Lock()
+	cancel := m.cancelSvc
+	m.ctxMu.Unlock()
+	cancel(deadline)
+}
+
+// stopService stops the service in service mode and waits run function, controls of the service manager
+// are answered with stop pending status meanwhile.
+func (m *manager) stopService(r <-chan request, status *statusReporter, finishRun <-chan struct{}, timeout time.Duration) {
+	// stop pending is set before interrogate is answered, so the answer is not the previous state
+	status.set(svc.Status{State: svc.StopPending, WaitHint: waitHint(timeout)})
+	done := make(chan struct{})
+	answered := status.answer(r, done)
+	defer func() {
+		close(done)
+		<-answered
+	}()
+
+	m.stopping(status, timeout)
+	m.waitRun(finishRun, timeout)
+}
+
+// stopping changes state to stop pending, calls hooks, drains and cancels context of run function.
+// Progress of draining is reported to status if it is not nil.
+func (m *manager) stopping(status *statusReporter, timeout time.Duration) {
+	deadline := m.clock.Now().Add(timeout)
+	m.setState(svc.StopPending)
+	m.callHooks(m.onStop)
+	m.drain(status, timeout)
+	m.cancelRun(deadline) // cancel context svcHandler
+}
+
+// setState sets the current state of service.
+func (m *manager) setState(s svc.State) {
+	atomic.StoreUint32(&m.state, uint32(s))
+}
+
+// callHooks calls hooks in order of registration.
+func (m *manager) callHooks(hooks []func()) {
+	for _, h := range hooks {
+		h()
+	}
+}
+
+// report writes entry to event log if it is opened.
+func (m *manager) report(level Level, code uint16, msg string) {
+	if m.elog != nil && severity(level) >= severity(m.logLevel) {
+		m.elog.Report(level, 0, code, msg)
+	}
+}
@@ -5,10 +5,17 @@ package winsvc
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
 )
 
 func TestRun_Interrupt(t *testing.T) {
@@ -44,7 +51,6 @@ func TestRun_Panic(t *testing.T) {
 }
 
 func TestRun_DisablePanic(t *testing.T) {
-	runOnce = sync.Once{}
 	defer func() {
 		if r := recover(); r != nil {
 			t.Errorf("exp: nil")
@@ -53,3 +59,402 @@ func TestRun_DisablePanic(t *testing.T) {
 
 	start(func(_ context.Context) {}, DisablePanic())
 }
+
+func TestRunE(t *testing.T) {
+	if err := RunE(func(_ context.Context) {}); err != ErrRunExited {
+		t.Errorf("exp: %v, got: %v", ErrRunExited, err)
+	}
+
+	if err := RunE(func(_ context.Context) { panic("failure") }); err == nil || ExitCode(err) != 1 {
+		t.Errorf("exp: error of panic, got: %v", err)
+	}
+
+	h := New(func(ctx context.Context) { <-ctx.Done() }, signalNotify(func(c chan<- os.Signal, sig ...os.Signal) { c <- os.Interrupt }))
+	h.SetExitCode(3)
+	if code := ExitCode(h.RunE()); code != 3 {
+		t.Errorf("exp: %d, got: %d", 3, code)
+	}
+}
+
+// controlScript drives Execute of the manager with requests and returns emitted states.
+// CurrentStatus of request is filled by the last emitted status like OS service manager does.
+// Statuses are sent synchronously and requests are handled in order, so states are in order of requests
+// without waiting between them.
+func controlScript(t *testing.T, m *manager, cmds ...svc.Cmd) []svc.State {
+	t.Helper()
+	m.ctxSvc, m.cancelSvc = newRunContext()
+
+	r := make(chan svc.ChangeRequest)
+	changes := make(chan svc.Status)
+	executed := make(chan struct{})
+	go func() {
+		defer close(executed)
+		m.Execute(nil, r, changes)
+	}()
+
+	var (
+		mu      sync.Mutex
+		states  []svc.State
+		current svc.Status
+	)
+	// statuses are collected until Execute returns
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case s := <-changes:
+				mu.Lock()
+				states, current = append(states, s.State), s
+				mu.Unlock()
+			case <-executed:
+				return
+			}
+		}
+	}()
+
+	for _, cmd := range cmds {
+		mu.Lock()
+		req := svc.ChangeRequest{Cmd: cmd, CurrentStatus: current}
+		mu.Unlock()
+
+		select {
+		case r <- req:
+		case <-done:
+			t.Fatalf("execute has returned before %d command", cmd)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("execute has not returned")
+	}
+	return states
+}
+
+func TestExecute_Script(t *testing.T) {
+	m := newManager(func(ctx context.Context) { <-ctx.Done() })
+	got := controlScript(t, m, svc.Interrogate, svc.Pause, svc.Continue, svc.Stop)
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestExecute_Shutdown(t *testing.T) {
+	m := newManager(func(ctx context.Context) { <-ctx.Done() })
+	got := controlScript(t, m, svc.Shutdown)
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestExecute_ControlsWhileStopping(t *testing.T) {
+	m := newManager(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(time.Second) // long stop
+	})
+	got := controlScript(t, m, svc.Stop, svc.Interrogate, svc.Pause)
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestExecute_RepeatedStop(t *testing.T) {
+	m := newManager(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(time.Millisecond * 500)
+	})
+	var stops int
+	m.onStop = append(m.onStop, func() { stops++ })
+	got := controlScript(t, m, svc.Stop, svc.Stop, svc.Shutdown, svc.Interrogate)
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if stops != 1 {
+		t.Errorf("exp: %d, got: %d", 1, stops)
+	}
+}
+
+func TestExecute_StopTimeout(t *testing.T) {
+	c := newFakeClock()
+	m := newManager(func(ctx context.Context) { select {} }, TimeoutStop(time.Hour), withClock(c))
+	go func() {
+		c.WaitTimers(1) // waiting of run function
+		c.Advance(time.Hour)
+	}()
+	got := controlScript(t, m, svc.Stop)
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestExecute_RestartBackoff(t *testing.T) {
+	var (
+		runs int32
+		m    *manager
+	)
+	m = newManager(func(ctx context.Context) {
+		if atomic.AddInt32(&runs, 1) < 3 {
+			return // crash twice
+		}
+		m.stopOnce.Do(func() { close(m.stopReq) }) // the third run stops the service
+		<-ctx.Done()
+	}, WithRestartBackoff(time.Millisecond, time.Millisecond*10, 3))
+	got := controlScript(t, m)
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.StopPending}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if n := atomic.LoadInt32(&runs); n != 3 {
+		t.Errorf("exp: %d, got: %d", 3, n)
+	}
+}
+
+func TestExecute_RestartBackoffExhausted(t *testing.T) {
+	m := newManager(func(_ context.Context) {}, DisablePanic(), WithRestartBackoff(time.Millisecond, time.Millisecond*10, 2))
+	got := controlScript(t, m)
+
+	exp := []svc.State{svc.StartPending, svc.Running}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestRun_Signals(t *testing.T) {
+	var got []os.Signal
+	start(func(ctx context.Context) {
+		<-ctx.Done()
+	}, WithSignals(os.Interrupt), signalNotify(func(c chan<- os.Signal, sig ...os.Signal) {
+		got = sig
+		c <- os.Interrupt
+	}))
+
+	exp := []os.Signal{os.Interrupt}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+}
+
+func TestManager_ControlTimeout(t *testing.T) {
+	m := newManager(nil, TimeoutStop(time.Minute), TimeoutShutdown(time.Second*5))
+	tests := []struct {
+		cmd svc.Cmd
+		exp time.Duration
+	}{
+		{svc.Stop, time.Minute},
+		{svc.Shutdown, time.Second * 5},
+		{svc.PreShutdown, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := m.controlTimeout(tt.cmd); got != tt.exp {
+			t.Errorf("%d exp: %v, got: %v", tt.cmd, tt.exp, got)
+		}
+	}
+}
+
+func TestManager_ControlTimeoutKill(t *testing.T) {
+	m := newManager(nil, TimeoutStop(time.Minute))
+	m.killTimeout = time.Second * 5
+
+	if got := m.controlTimeout(svc.Shutdown); got != m.killTimeout {
+		t.Errorf("exp: %v, got: %v", m.killTimeout, got)
+	}
+
+	if got := m.controlTimeout(svc.Stop); got != time.Minute {
+		t.Errorf("exp: %v, got: %v", time.Minute, got)
+	}
+}
+
+func TestManager_AcceptedControls(t *testing.T) {
+	tests := []struct {
+		opts []option
+		exp  svc.Accepted
+	}{
+		{nil, svc.AcceptStop | svc.AcceptShutdown},
+		{[]option{WithAcceptedControls(svc.AcceptStop)}, svc.AcceptStop},
+		{[]option{WithAcceptedControls(svc.AcceptStop), TimeoutPreShutdown(time.Minute)}, svc.AcceptStop | svc.AcceptPreShutdown},
+		{[]option{IgnoreShutdown(), TimeoutPreShutdown(time.Minute)}, svc.AcceptStop},
+	}
+
+	for _, tt := range tests {
+		if got := newManager(nil, tt.opts...).acceptedControls(); got != tt.exp {
+			t.Errorf("exp: %d, got: %d", tt.exp, got)
+		}
+	}
+}
+
+func TestExecute_RunPanic(t *testing.T) {
+	m := newManager(func(ctx context.Context) { panic("failure") })
+	m.interactive = false
+	m.ctxSvc, m.cancelSvc = newRunContext()
+
+	changes := make(chan svc.Status, 10)
+	ssec, code := m.Execute(nil, make(chan svc.ChangeRequest), changes)
+	if ssec || code != uint32(windows.ERROR_EXCEPTION_IN_SERVICE) {
+		t.Errorf("exp: %d, got: %t %d", windows.ERROR_EXCEPTION_IN_SERVICE, ssec, code)
+	}
+
+	close(changes)
+	var last svc.State
+	for s := range changes {
+		last = s.State
+	}
+	if last != svc.StopPending {
+		t.Errorf("exp: %v, got: %v", svc.StopPending, last)
+	}
+}
+
+func TestManager_Chdir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	dir, err := ioutil.TempDir("", "winsvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := newManager(nil, DisableChdir()).chdir(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.Getwd(); got != wd {
+		t.Errorf("exp: %s, got: %s", wd, got)
+	}
+
+	if err := newManager(nil, ChdirTo(dir)).chdir(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.Getwd(); !strings.EqualFold(got, dir) {
+		t.Errorf("exp: %s, got: %s", dir, got)
+	}
+}
+
+func TestExecute_ScheduledRestartOncePerWindow(t *testing.T) {
+	var runs int32
+	c := newFakeClock() // 12:00
+	m := newManager(func(ctx context.Context) {
+		atomic.AddInt32(&runs, 1)
+		<-ctx.Done()
+	}, WithScheduledRestart("0-29 3 * * *"), withClock(c))
+	m.ctxSvc, m.cancelSvc = newRunContext()
+
+	r := make(chan svc.ChangeRequest)
+	changes := make(chan svc.Status)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Execute(nil, r, changes)
+	}()
+	go func() {
+		for {
+			select {
+			case <-changes:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	waitRuns := func(n int32) {
+		t.Helper()
+		for i := 0; i < 1000 && atomic.LoadInt32(&runs) < n; i++ {
+			time.Sleep(time.Millisecond * 5)
+		}
+		if got := atomic.LoadInt32(&runs); got != n {
+			t.Fatalf("exp: %d runs, got: %d", n, got)
+		}
+	}
+
+	waitRuns(1)
+	c.Advance(time.Hour * 15) // 3:00
+	for day := int32(1); day <= 2; day++ {
+		// minutes of the window and the rest of the hour
+		for i := 0; i < 60; i++ {
+			c.Advance(time.Minute)
+			time.Sleep(time.Millisecond * 5)
+		}
+		waitRuns(day + 1)
+		c.Advance(time.Hour * 23)
+	}
+
+	r <- svc.ChangeRequest{Cmd: svc.Stop}
+	<-done
+	if got := atomic.LoadInt32(&runs); got != 3 {
+		t.Errorf("exp: one restart per window, got: %d runs", got)
+	}
+}
+
+func TestExecute_RestartWithoutOverlap(t *testing.T) {
+	var active, overlaps, runs int32
+	release := make(chan struct{})
+	m := newManager(func(ctx context.Context) {
+		if atomic.AddInt32(&active, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		defer atomic.AddInt32(&active, -1)
+
+		first := atomic.AddInt32(&runs, 1) == 1
+		<-ctx.Done()
+		if first {
+			<-release // slow stop of the first run
+		}
+	})
+	m.ctxSvc, m.cancelSvc = newRunContext()
+
+	r := make(chan svc.ChangeRequest)
+	changes := make(chan svc.Status, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Execute(nil, r, changes)
+	}()
+	<-changes // start pending
+	<-changes // running
+
+	m.restartReq <- "test"
+	r <- svc.ChangeRequest{Cmd: svc.Interrogate}
+	select {
+	case s := <-changes:
+		if s.State != svc.Running {
+			t.Errorf("exp: %v, got: %v", svc.Running, s.State)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("interrogate is not answered during restart")
+	}
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("exp: next run after the previous one returns, got: %d runs", n)
+	}
+
+	close(release)
+	for i := 0; i < 1000 && atomic.LoadInt32(&runs) < 2; i++ {
+		time.Sleep(time.Millisecond * 5)
+	}
+	r <- svc.ChangeRequest{Cmd: svc.Stop}
+	<-done
+
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("exp: %d, got: %d", 2, n)
+	}
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Errorf("exp: no overlapped runs, got: %d", n)
+	}
+}
@@ -0,0 +1,169 @@
+// +build windows
+
+package winsvctest
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+var (
+	// ErrExited is returned when control is sent after Execute has returned.
+	ErrExited = errors.New("execute has returned")
+	// ErrTimeout is returned when Execute does not accept control, report state or return in time.
+	ErrTimeout = errors.New("timeout of execute")
+)
+
+// Control is a fake service control manager which sends controls to Execute of svc.Handler and records its statuses,
+// so the control loop of the service (stop, pause, interrogate) is tested without the service control manager of the system.
+type Control struct {
+	// Timeout bounds every wait of Control, default is 5s.
+	Timeout time.Duration
+
+	requests chan svc.ChangeRequest
+	done     chan struct{}
+
+	mu       sync.Mutex
+	statuses []svc.Status
+	changed  chan struct{} // it is closed and replaced when status is reported
+	ssec     bool
+	errno    uint32
+}
+
+// Execute runs Execute of the handler with arguments of start (the first one is name of the service) in goroutine,
+// it is started like the service control manager starts the service.
+func Execute(h svc.Handler, args ...string) *Control {
+	c := &Control{
+		Timeout:  time.Second * 5,
+		requests: make(chan svc.ChangeRequest),
+		done:     make(chan struct{}),
+		changed:  make(chan struct{}),
+	}
+
+	changes := make(chan svc.Status)
+	executed := make(chan struct{})
+	go func() {
+		defer close(executed)
+		ssec, errno := h.Execute(args, c.requests, changes)
+
+		c.mu.Lock()
+		c.ssec, c.errno = ssec, errno
+		c.mu.Unlock()
+	}()
+
+	go func() {
+		defer close(c.done)
+		for {
+			select {
+			case s := <-changes:
+				c.mu.Lock()
+				c.statuses = append(c.statuses, s)
+				close(c.changed)
+				c.changed = make(chan struct{})
+				c.mu.Unlock()
+			case <-executed:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// Send sends control to Execute with the current status, it returns when Execute has received it.
+func (c *Control) Send(cmd svc.Cmd) error {
+	select {
+	case c.requests <- svc.ChangeRequest{Cmd: cmd, CurrentStatus: c.Current()}:
+		return nil
+	case <-c.done:
+		return ErrExited
+	case <-time.After(c.Timeout):
+		return ErrTimeout
+	}
+}
+
+// WaitState waits until Execute reports the state.
+func (c *Control) WaitState(state svc.State) error {
+	timeout := time.After(c.Timeout)
+	for {
+		c.mu.Lock()
+		current, changed := c.current(), c.changed
+		c.mu.Unlock()
+
+		if current.State == state {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-c.done:
+			if c.Current().State == state {
+				return nil
+			}
+			return ErrExited
+		case <-timeout:
+			return ErrTimeout
+		}
+	}
+}
+
+// Stop sends stop control and waits until Execute returns, it returns exit code of Execute.
+func (c *Control) Stop() (svcSpecificEC bool, exitCode uint32, err error) {
+	if err := c.Send(svc.Stop); err != nil && err != ErrExited {
+		return false, 0, err
+	}
+	return c.Wait()
+}
+
+// Wait waits until Execute returns, it returns exit code of Execute.
+func (c *Control) Wait() (svcSpecificEC bool, exitCode uint32, err error) {
+	select {
+	case <-c.done:
+	case <-time.After(c.Timeout):
+		return false, 0, ErrTimeout
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ssec, c.errno, nil
+}
+
+// Current returns the last reported status, it is stopped status before the first report.
+func (c *Control) Current() svc.Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current()
+}
+
+// Statuses returns all reported statuses in order of reports.
+func (c *Control) Statuses() []svc.Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]svc.Status(nil), c.statuses...)
+}
+
+// States returns states of all reported statuses in order of reports.
+func (c *Control) States() []svc.State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	states := make([]svc.State, 0, len(c.statuses))
+	for _, s := range c.statuses {
+		states = append(states, s.State)
+	}
+	return states
+}
+
+// Done returns channel which is closed when Execute returns.
+func (c *Control) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Control) current() svc.Status {
+	if len(c.statuses) == 0 {
+		return svc.Status{State: svc.Stopped}
+	}
+	return c.statuses[len(c.statuses)-1]
+}
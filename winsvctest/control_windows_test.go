@@ -0,0 +1,65 @@
+// +build windows
+
+package winsvctest
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+type testHandler struct{}
+
+func (testHandler) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	const accepts = svc.AcceptStop | svc.AcceptPauseAndContinue
+	changes <- svc.Status{State: svc.StartPending}
+	changes <- svc.Status{State: svc.Running, Accepts: accepts}
+	for c := range r {
+		switch c.Cmd {
+		case svc.Interrogate:
+			changes <- c.CurrentStatus
+		case svc.Pause:
+			changes <- svc.Status{State: svc.Paused, Accepts: accepts}
+		case svc.Continue:
+			changes <- svc.Status{State: svc.Running, Accepts: accepts}
+		case svc.Stop:
+			changes <- svc.Status{State: svc.StopPending}
+			return true, 2
+		}
+	}
+	return false, 0
+}
+
+func TestControl(t *testing.T) {
+	c := Execute(testHandler{}, "test")
+	if err := c.WaitState(svc.Running); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range []svc.Cmd{svc.Interrogate, svc.Pause, svc.Continue} {
+		if err := c.Send(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.WaitState(svc.Running); err != nil {
+		t.Fatal(err)
+	}
+
+	ssec, code, err := c.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ssec || code != 2 {
+		t.Errorf("exp: service-specific code 2, got: %t %d", ssec, code)
+	}
+
+	exp := []svc.State{svc.StartPending, svc.Running, svc.Running, svc.Paused, svc.Running, svc.StopPending}
+	if got := c.States(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("exp: %v, got: %v", exp, got)
+	}
+
+	if err := c.Send(svc.Interrogate); err != ErrExited {
+		t.Errorf("exp: %v, got: %v", ErrExited, err)
+	}
+}
@@ -0,0 +1,266 @@
+// Package winsvctest provides in-memory service control manager for tests of programs using winsvc.
+// It works on any OS and does not touch the service control manager of the system.
+package winsvctest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/itcomusic/winsvc"
+)
+
+var (
+	// ErrDisabled is returned when the disabled service is started.
+	ErrDisabled = errors.New("service is disabled")
+	// ErrAlreadyRunning is returned when the running service is started.
+	ErrAlreadyRunning = errors.New("service is already running")
+	// ErrNoName is returned when the service without name is installed.
+	ErrNoName = errors.New("service name is empty")
+)
+
+// RunFunc is a run function of the service, it must return when context is canceled.
+type RunFunc func(ctx context.Context)
+
+// Manager is in-memory service control manager, it implements winsvc.Manager.
+type Manager struct {
+	// TimeoutStop is a timeout of stopping run function, default is 20s.
+	TimeoutStop time.Duration
+
+	mu       sync.Mutex
+	services map[string]*service
+	runs     map[string]RunFunc
+	fails    map[string]error
+}
+
+type service struct {
+	config winsvc.Config
+	state  winsvc.State
+	args   []string
+	exited bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ winsvc.Manager = (*Manager)(nil)
+
+// NewManager returns empty manager.
+func NewManager() *Manager {
+	return &Manager{
+		TimeoutStop: time.Second * 20,
+		services:    make(map[string]*service),
+		runs:        make(map[string]RunFunc),
+		fails:       make(map[string]error),
+	}
+}
+
+// Register sets run function of the service, it is called when the service starts.
+// Service without run function is running until it is stopped.
+func (m *Manager) Register(name string, run RunFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[name] = run
+}
+
+// FailNext makes the next operation op (install, uninstall, start, stop, restart, status) return err.
+func (m *Manager) FailNext(op string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fails[op] = err
+}
+
+// Config returns configuration of the installed service.
+func (m *Manager) Config(name string) (winsvc.Config, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.services[name]
+	if !ok {
+		return winsvc.Config{}, false
+	}
+	return s.config, true
+}
+
+// Args returns arguments of the last start of the service.
+func (m *Manager) Args(name string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.services[name]; ok {
+		return s.args
+	}
+	return nil
+}
+
+// Exited reports whether run function of the service has returned before the stop.
+func (m *Manager) Exited(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.services[name]
+	return ok && s.exited
+}
+
+// Install creates the service.
+func (m *Manager) Install(c winsvc.Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("install", c.Name); err != nil {
+		return err
+	}
+
+	if c.Name == "" {
+		return &winsvc.Error{Op: "install", Err: ErrNoName}
+	}
+
+	for name, s := range m.services {
+		if name == c.Name || (c.DisplayName != "" && s.config.DisplayName == c.DisplayName) {
+			return &winsvc.Error{Op: "install", Name: c.Name, Err: winsvc.ErrAlreadyExists}
+		}
+	}
+
+	m.services[c.Name] = &service{config: c, state: winsvc.Stopped}
+	return nil
+}
+
+// Uninstall stops and deletes the service.
+func (m *Manager) Uninstall(name string) error {
+	if err := m.Stop(name); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("uninstall", name); err != nil {
+		return err
+	}
+
+	if _, ok := m.services[name]; !ok {
+		return &winsvc.Error{Op: "uninstall", Name: name, Err: winsvc.ErrNotInstalled}
+	}
+	delete(m.services, name)
+	return nil
+}
+
+// Start starts the service and its run function.
+func (m *Manager) Start(name string, args ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("start", name); err != nil {
+		return err
+	}
+
+	s, ok := m.services[name]
+	switch {
+	case !ok:
+		return &winsvc.Error{Op: "start", Name: name, Err: winsvc.ErrNotInstalled}
+	case s.config.StartType == winsvc.StartDisabled:
+		return &winsvc.Error{Op: "start", Name: name, Err: ErrDisabled}
+	case s.state != winsvc.Stopped:
+		return &winsvc.Error{Op: "start", Name: name, Err: ErrAlreadyRunning}
+	}
+
+	run, ok := m.runs[name]
+	if !ok {
+		run = func(ctx context.Context) { <-ctx.Done() }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.state = winsvc.Running
+	s.args = args
+	s.exited = false
+	s.cancel = cancel
+	s.done = done
+
+	go func() {
+		defer close(done)
+		run(ctx)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if ctx.Err() == nil {
+			s.exited = true
+		}
+		s.state = winsvc.Stopped
+	}()
+	return nil
+}
+
+// Stop cancels context of run function and waits until it returns.
+func (m *Manager) Stop(name string) error {
+	m.mu.Lock()
+	if err := m.fail("stop", name); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	s, ok := m.services[name]
+	if !ok {
+		m.mu.Unlock()
+		return &winsvc.Error{Op: "stop", Name: name, Err: winsvc.ErrNotInstalled}
+	}
+
+	if s.state == winsvc.Stopped {
+		m.mu.Unlock()
+		return nil
+	}
+
+	s.state = winsvc.StopPending
+	s.cancel()
+	done := s.done
+	m.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(m.TimeoutStop):
+		return &winsvc.Error{Op: "stop", Name: name, Err: winsvc.ErrTimeout}
+	}
+}
+
+// Restart stops the service and starts it again.
+func (m *Manager) Restart(name string, args ...string) error {
+	m.mu.Lock()
+	err := m.fail("restart", name)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := m.Stop(name); err != nil {
+		return err
+	}
+	return m.Start(name, args...)
+}
+
+// Status returns the current state of the service.
+func (m *Manager) Status(name string) (winsvc.State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("status", name); err != nil {
+		return 0, err
+	}
+
+	s, ok := m.services[name]
+	if !ok {
+		return 0, &winsvc.Error{Op: "status", Name: name, Err: winsvc.ErrNotInstalled}
+	}
+	return s.state, nil
+}
+
+// fail returns injected error of operation once.
+func (m *Manager) fail(op, name string) error {
+	err, ok := m.fails[op]
+	if !ok {
+		return nil
+	}
+
+	delete(m.fails, op)
+	return &winsvc.Error{Op: op, Name: name, Err: err}
+}
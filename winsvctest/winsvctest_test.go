@@ -0,0 +1,115 @@
+package winsvctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itcomusic/winsvc"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	stopped := make(chan struct{})
+	m.Register("test", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	if err := m.Install(winsvc.Config{Name: "test", DisplayName: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Install(winsvc.Config{Name: "other", DisplayName: "Test"}); !errors.Is(err, winsvc.ErrAlreadyExists) {
+		t.Errorf("exp: %v, got: %v", winsvc.ErrAlreadyExists, err)
+	}
+
+	if err := m.Start("test", "-v"); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, _ := m.Status("test"); state != winsvc.Running {
+		t.Errorf("exp: %s, got: %s", winsvc.Running, state)
+	}
+
+	if err := m.Uninstall("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Errorf("exp: run function is stopped")
+	}
+
+	if _, err := m.Status("test"); !errors.Is(err, winsvc.ErrNotInstalled) {
+		t.Errorf("exp: %v, got: %v", winsvc.ErrNotInstalled, err)
+	}
+}
+
+func TestManager_Exited(t *testing.T) {
+	m := NewManager()
+	m.Register("test", func(_ context.Context) {})
+	if err := m.Install(winsvc.Config{Name: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Start("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for !m.Exited("test") {
+		if time.Now().After(deadline) {
+			t.Fatal("exp: run function has exited")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if state, _ := m.Status("test"); state != winsvc.Stopped {
+		t.Errorf("exp: %s, got: %s", winsvc.Stopped, state)
+	}
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	m := NewManager()
+	m.TimeoutStop = time.Millisecond * 10
+	release := make(chan struct{})
+	defer close(release)
+	m.Register("test", func(_ context.Context) { <-release })
+
+	if err := m.Install(winsvc.Config{Name: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Start("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Stop("test"); !errors.Is(err, winsvc.ErrTimeout) {
+		t.Errorf("exp: %v, got: %v", winsvc.ErrTimeout, err)
+	}
+}
+
+func TestManager_FailNext(t *testing.T) {
+	m := NewManager()
+	errTest := errors.New("test")
+	m.FailNext("install", errTest)
+
+	if err := m.Install(winsvc.Config{Name: "test"}); !errors.Is(err, errTest) {
+		t.Errorf("exp: %v, got: %v", errTest, err)
+	}
+
+	if err := m.Install(winsvc.Config{Name: "test"}); err != nil {
+		t.Errorf("exp: nil, got: %v", err)
+	}
+
+	if err := m.Start("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Start("test"); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("exp: %v, got: %v", ErrAlreadyRunning, err)
+	}
+}
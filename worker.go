@@ -0,0 +1,165 @@
+// +build windows
+
+package winsvc
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	logonService        = 5   // LOGON32_LOGON_SERVICE
+	disableMaxPrivilege = 0x1 // DISABLE_MAX_PRIVILEGE of CreateRestrictedToken
+)
+
+var (
+	procLogonUserW            = modadvapi32.NewProc("LogonUserW")
+	procCreateRestrictedToken = modadvapi32.NewProc("CreateRestrictedToken")
+)
+
+// processToken returns token of the process under the account or restricted token of the service,
+// it returns zero token if the process runs with token of the service.
+func (p Process) processToken() (windows.Token, error) {
+	if p.Account == "" {
+		if p.Restricted {
+			return restrictedToken()
+		}
+		return 0, nil
+	}
+
+	pw, err := p.password()
+	if err != nil {
+		return 0, err
+	}
+	defer pw.wipe()
+
+	token, err := logonUser(p.Account, pw)
+	if err != nil {
+		return 0, fmt.Errorf("logon %s: %w", p.Account, err)
+	}
+	return token, nil
+}
+
+// password returns password of the account of the process.
+func (p Process) password() (*password, error) {
+	if p.PasswordCredential == "" {
+		return passwordFromString(p.Password), nil
+	}
+
+	var pw *password
+	err := readCredential(p.PasswordCredential, func(_ string, blob []uint16) {
+		pw = newPassword(blob)
+	})
+	return pw, err
+}
+
+// logonUser logs on the account as service, built-in accounts (NT AUTHORITY\LocalService) have no password.
+func logonUser(account string, pw *password) (windows.Token, error) {
+	domain, user := ".", account
+	switch {
+	case strings.Contains(account, `\`):
+		parts := strings.SplitN(account, `\`, 2)
+		domain, user = parts[0], parts[1]
+	case strings.Contains(account, "@"):
+		domain = ""
+	}
+
+	var pwPtr *uint16
+	if !pw.empty() {
+		pwPtr = pw.ptr()
+	}
+
+	var token windows.Token
+	r, _, err := procLogonUserW.Call(uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(user))),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(domain))), uintptr(unsafe.Pointer(pwPtr)),
+		logonService, 0, uintptr(unsafe.Pointer(&token)))
+	if r == 0 {
+		return 0, err
+	}
+	return token, nil
+}
+
+// restrictedToken returns token of the current process without privileges (except SeChangeNotifyPrivilege)
+// and with group of administrators which is used only to deny access.
+func restrictedToken() (windows.Token, error) {
+	var token windows.Token
+	err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_DUPLICATE|windows.TOKEN_QUERY|windows.TOKEN_ASSIGN_PRIMARY, &token)
+	if err != nil {
+		return 0, err
+	}
+	defer token.Close()
+
+	admins, err := windows.CreateWellKnownSid(windows.WinBuiltinAdministratorsSid)
+	if err != nil {
+		return 0, err
+	}
+	disable := windows.SIDAndAttributes{Sid: admins}
+
+	var restricted windows.Token
+	r, _, err := procCreateRestrictedToken.Call(uintptr(token), disableMaxPrivilege,
+		1, uintptr(unsafe.Pointer(&disable)), 0, 0, 0, 0, uintptr(unsafe.Pointer(&restricted)))
+	if r == 0 {
+		return 0, err
+	}
+	return restricted, nil
+}
+
+// newKillJob creates job object which kills its processes when the last handle of the job is closed,
+// so processes do not outlive the service even if it crashes.
+func newKillJob() (windows.Handle, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	_, err = windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+// assignJob assigns the process to the job.
+func assignJob(job windows.Handle, pid int) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.AssignProcessToJobObject(job, h)
+}
+
+// resumeProcess resumes the process which is created suspended, it has the only thread then.
+func resumeProcess(pid int) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != uint32(pid) {
+			continue
+		}
+
+		h, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			return err
+		}
+		_, err = windows.ResumeThread(h)
+		windows.CloseHandle(h)
+		return err
+	}
+	return fmt.Errorf("thread of process %d is not found: %w", pid, err)
+}
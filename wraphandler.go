@@ -0,0 +1,76 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// WrapHandler returns run function which runs existing svc.Handler, so the handler gets install, recovery and actions
+// of winsvc without rewrite of its control loop. Execute gets name of the service and arguments of start (see StartArgs),
+// stop of the service is sent to it as svc.Stop control and it must return then. Not zero exit code of Execute
+// is set as exit code of the service (see Handle.SetExitCode). Statuses of the handler are discarded,
+// the service manager is notified by winsvc, and other controls are not sent to the handler.
+//
+//	winsvc.Run(winsvc.WrapHandler(&handler{}))
+func WrapHandler(h svc.Handler) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		r := make(chan svc.ChangeRequest)
+		changes := make(chan svc.Status)
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			var (
+				current svc.Status
+				req     chan<- svc.ChangeRequest // it is set after stop
+				stop    = ctx.Done()
+			)
+			for {
+				select {
+				case current = <-changes:
+				case <-stop:
+					stop, req = nil, r
+				case req <- svc.ChangeRequest{Cmd: svc.Stop, CurrentStatus: current}:
+					req = nil
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		_, code := h.Execute(append([]string{serviceName(ctx)}, StartArgs(ctx)...), r, changes)
+		if m, ok := ctx.Value(managerKey{}).(*manager); ok && code != 0 {
+			atomic.StoreUint32(&m.exitCode, code)
+		}
+	}
+}
+
+// serviceName returns name of the service which runs run function of context,
+// it is name of the executable if context is not of run function.
+func serviceName(ctx context.Context) string {
+	if m, ok := ctx.Value(managerKey{}).(*manager); ok {
+		if m.name != "" {
+			return m.name
+		}
+		if c, err := m.effectiveConfig(); err == nil {
+			return c.Name
+		}
+	}
+	return executableName()
+}
+
+// executableName returns name of the executable without extension.
+func executableName() string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	return strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+}
@@ -0,0 +1,60 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+type testHandler struct {
+	args []string
+	cmds []svc.Cmd
+	code uint32
+}
+
+func (h *testHandler) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	h.args = args
+	changes <- svc.Status{State: svc.StartPending}
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop}
+	for c := range r {
+		h.cmds = append(h.cmds, c.Cmd)
+		if c.Cmd == svc.Stop {
+			if c.CurrentStatus.State != svc.Running {
+				h.cmds = append(h.cmds, svc.Interrogate) // unexpected status
+			}
+			changes <- svc.Status{State: svc.StopPending}
+			return true, h.code
+		}
+	}
+	return false, 0
+}
+
+func TestWrapHandler(t *testing.T) {
+	th := &testHandler{code: 3}
+	m := newManager(WrapHandler(th))
+	m.name = "app"
+	m.startArgs = []string{"-debug"}
+
+	controlScript(t, m, svc.Stop)
+	if exp := []svc.Cmd{svc.Stop}; !reflect.DeepEqual(th.cmds, exp) {
+		t.Errorf("exp: %v, got: %v", exp, th.cmds)
+	}
+	if exp := []string{"app", "-debug"}; !reflect.DeepEqual(th.args, exp) {
+		t.Errorf("exp: %v, got: %v", exp, th.args)
+	}
+	if code := atomic.LoadUint32(&m.exitCode); code != 3 {
+		t.Errorf("exp: %d, got: %d", 3, code)
+	}
+}
+
+func TestWrapHandler_Exit(t *testing.T) {
+	run := WrapHandler(&testHandler{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	run(ctx) // returns after stop
+}
@@ -0,0 +1,167 @@
+// +build windows
+
+package winsvc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modkernel32      = windows.NewLazySystemDLL("kernel32.dll")
+	procAllocConsole = modkernel32.NewProc("AllocConsole")
+
+	consoleOnce sync.Once
+)
+
+// Process is an external executable which is supervised by the service, so any program can run as windows service.
+//
+//	winsvc.Run(winsvc.Process{Path: `C:\nginx\nginx.exe`, StopCommand: []string{`C:\nginx\nginx.exe`, "-s", "quit"}}.Run)
+type Process struct {
+	Path string
+	Args []string
+	Dir  string   // working directory, default is the current directory
+	Env  []string // environment of the process, default is environment of the service
+
+	// Account of the process for split-privilege design: NT AUTHORITY\LocalService, NT AUTHORITY\NetworkService
+	// or DOMAIN\user with right to log on as service, default is account of the service.
+	// The service must run as LocalSystem to start process under other account.
+	Account            string
+	Password           string // password of the account, built-in accounts have no password, copies of string can not be wiped
+	PasswordCredential string // target of generic credential of Credential Manager, it is used if Password is empty
+	Restricted         bool   // process runs with token of the service without privileges and administrators group, it is used if Account is empty
+
+	Stdout io.Writer // output of the process, default is discarded
+	Stderr io.Writer // errors of the process, default is discarded
+
+	RestartDelay time.Duration // delay before restart of exited process, default is 1s
+	StopCommand  []string      // command of graceful shutdown, CTRL_BREAK is sent to the process if it is empty
+	StopTimeout  time.Duration // stop command and process are killed if the process has not exited in time after stop, default is 10s
+}
+
+// processStartAttempts is a count of failed starts of the process in a row after which Run returns.
+const processStartAttempts = 5
+
+// Run starts the process and restarts it on exit until context is canceled, then the process is stopped.
+// It has signature of run function of the service. Processes are in job object of the service,
+// so they are killed if the service crashes. Errors are written to event log of the service,
+// Run returns at once if the account can not log on and after several failed starts in a row.
+func (p Process) Run(ctx context.Context) {
+	delay := p.RestartDelay
+	if delay == 0 {
+		delay = time.Second
+	}
+
+	token, err := p.processToken()
+	if err != nil {
+		reportContext(ctx, LevelError, codeProcessFailed, fmt.Sprintf("process %s: %v", p.Path, err))
+		return
+	}
+	if token != 0 {
+		defer token.Close()
+	}
+
+	// job is not supported by old systems if the service is already in job, processes are not killed with the service then
+	job, err := newKillJob()
+	if err == nil {
+		defer windows.CloseHandle(job)
+	}
+
+	for failures := 0; ; {
+		if err := p.runOnce(ctx, token, job); err != nil {
+			failures++
+			reportContext(ctx, LevelError, codeProcessFailed, fmt.Sprintf("start process %s (attempt %d of %d): %v",
+				p.Path, failures, processStartAttempts, err))
+			if failures >= processStartAttempts {
+				return
+			}
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runOnce runs the process until it exits or context is canceled, it returns error if the process is not started.
+func (p Process) runOnce(ctx context.Context, token windows.Token, job windows.Handle) error {
+	cmd := exec.Command(p.Path, p.Args...)
+	cmd.Dir = p.Dir
+	cmd.Env = p.Env
+	cmd.Stdout = p.Stdout
+	cmd.Stderr = p.Stderr
+	// own group of process receives CTRL_BREAK without the service
+	// process is suspended until it is in the job, so its children are in the job too
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.CREATE_SUSPENDED, Token: syscall.Token(token)}
+
+	// services have no console, but the process and the service must share it for CTRL_BREAK
+	consoleOnce.Do(func() { procAllocConsole.Call() })
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if job != 0 {
+		assignJob(job, cmd.Process.Pid)
+	}
+	if err := resumeProcess(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		cmd.Wait()
+	}()
+
+	select {
+	case <-exited:
+		return nil
+	case <-ctx.Done():
+	}
+
+	timeout := p.StopTimeout
+	if timeout == 0 {
+		timeout = time.Second * 10
+	}
+	stopCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	p.stop(stopCtx, cmd)
+	select {
+	case <-exited:
+	case <-stopCtx.Done():
+		cmd.Process.Kill()
+		<-exited
+	}
+	return nil
+}
+
+// stop requests graceful shutdown of the process, stop command is killed when context is done.
+func (p Process) stop(ctx context.Context, cmd *exec.Cmd) {
+	if len(p.StopCommand) > 0 {
+		stop := exec.CommandContext(ctx, p.StopCommand[0], p.StopCommand[1:]...)
+		stop.Dir = p.Dir
+		stop.Env = p.Env
+		if stop.Run() == nil {
+			return
+		}
+	}
+
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid)); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,73 @@
+// +build windows
+
+package winsvc
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestProcess_Restart(t *testing.T) {
+	var out bytes.Buffer
+	p := Process{Path: "cmd", Args: []string{"/c", "echo run"}, Stdout: &out, RestartDelay: time.Millisecond * 100}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	p.Run(ctx)
+
+	if got := strings.Count(out.String(), "run"); got < 2 {
+		t.Errorf("exp: restarts, got: %d runs", got)
+	}
+}
+
+func TestProcess_Stop(t *testing.T) {
+	p := Process{Path: "ping", Args: []string{"-n", "60", "127.0.0.1"}, StopTimeout: time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*500, cancel)
+
+	begin := time.Now()
+	p.Run(ctx)
+	if d := time.Since(begin); d > time.Second*5 {
+		t.Errorf("exp: stopped process, got: %s", d)
+	}
+}
+
+func TestProcess_StartFailed(t *testing.T) {
+	p := Process{Path: `C:\not-existed\app.exe`, RestartDelay: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	p.Run(ctx)
+
+	if ctx.Err() != nil {
+		t.Errorf("exp: Run returns after %d failed starts", processStartAttempts)
+	}
+}
+
+func TestRestrictedToken(t *testing.T) {
+	token, err := restrictedToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer token.Close()
+
+	privs, err := tokenPrivileges(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// DISABLE_MAX_PRIVILEGE keeps only SeChangeNotifyPrivilege
+	var notify windows.LUID
+	if err := windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr("SeChangeNotifyPrivilege"), &notify); err != nil {
+		t.Fatal(err)
+	}
+	if privs.PrivilegeCount != 1 || privs.Privileges[0].Luid != notify {
+		t.Errorf("exp: only SeChangeNotifyPrivilege, got: %d privileges", privs.PrivilegeCount)
+	}
+}